@@ -0,0 +1,123 @@
+package sweet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/internal/testpath"
+)
+
+// ArtifactsEnv is the environment variable setting the directory test
+// artifacts are kept in. It defaults to sweet-artifacts in [os.TempDir].
+const ArtifactsEnv = "SWEET_ARTIFACTS"
+
+var (
+	// artifactDirs holds the artifact directory of each test that asked for
+	// one.
+	artifactDirs sync.Map
+
+	// keptDirs holds the artifact directories of the tests that failed, so a
+	// parent test asking for its directory afterwards doesn't clear them.
+	keptDirs sync.Map
+)
+
+// Artifacts returns a directory for t to keep artifacts in: logs, dumps,
+// screenshots, anything that helps explain a failure.
+//
+// The directory is named after the test's full path, under [ArtifactsEnv] and
+// a directory for the test binary. It is deleted if the test passes, and kept,
+// with its path logged, if it fails, so CI can upload it.
+//
+// Every call for the same test returns the same directory. Any artifacts left
+// over from an earlier run of the test are removed on the first call, apart
+// from those its subtests have already kept.
+func Artifacts(t *testing.T) string {
+	t.Helper()
+
+	if dir, ok := artifactDirs.Load(t); ok {
+		return dir.(string)
+	}
+
+	root := os.Getenv(ArtifactsEnv)
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "sweet-artifacts")
+	}
+
+	dir := filepath.Join(root, binaryName(), testpath.FromName(t.Name()))
+	if err := clearArtifacts(dir); err != nil {
+		t.Fatalf("sweet: clearing old artifacts from %s: %s", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("sweet: creating artifact directory: %s", err)
+	}
+
+	if existing, loaded := artifactDirs.LoadOrStore(t, dir); loaded {
+		return existing.(string)
+	}
+
+	t.Cleanup(func() {
+		artifactDirs.Delete(t)
+
+		if t.Failed() {
+			keptDirs.Store(dir, true)
+			t.Logf("sweet: artifacts kept in %s", dir)
+			return
+		}
+
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("sweet: removing artifacts: %s", err)
+		}
+	})
+
+	return dir
+}
+
+// clearArtifacts removes everything in dir, apart from the directories kept
+// by failed tests.
+func clearArtifacts(dir string) error {
+	if !holdsKept(dir) {
+		return os.RemoveAll(dir)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if _, kept := keptDirs.Load(path); kept {
+			continue
+		}
+		if err := clearArtifacts(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// holdsKept reports whether a directory kept by a failed test is in dir.
+func holdsKept(dir string) bool {
+	prefix := dir + string(filepath.Separator)
+
+	holds := false
+	keptDirs.Range(func(key, _ any) bool {
+		holds = strings.HasPrefix(key.(string), prefix)
+		return !holds
+	})
+
+	return holds
+}
+
+// binaryName returns the name of the test binary, e.g. "sweet" for
+// sweet.test, which separates the artifacts of different packages.
+func binaryName() string {
+	name := filepath.Base(os.Args[0])
+	name = strings.TrimSuffix(name, ".exe")
+
+	return strings.TrimSuffix(name, ".test")
+}
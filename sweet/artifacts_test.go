@@ -0,0 +1,99 @@
+package sweet_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+func TestArtifacts(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(sweet.ArtifactsEnv, root)
+
+	t.Run("are removed when the test passes", func(t *testing.T) {
+		var dir string
+		sweet.Run(t, "passes", nil, func(t *testing.T, d any) {
+			dir = sweet.Artifacts(t)
+
+			if sweet.Artifacts(t) != dir {
+				t.Error("a second call gave a different directory")
+			}
+
+			if !strings.HasPrefix(dir, root) || !strings.HasSuffix(dir, filepath.Join("are_removed_when_the_test_passes", "passes")) {
+				t.Errorf("the directory is not named after the test: %s", dir)
+			}
+
+			if err := os.WriteFile(filepath.Join(dir, "log.txt"), []byte("all good"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("the artifacts were not removed: %v", err)
+		}
+	})
+
+	t.Run("are kept when the test fails", func(t *testing.T) {
+		out := runFailing(t, "TestArtifacts_failing", sweet.ArtifactsEnv+"="+root)
+
+		kept := regexp.MustCompile(`sweet: artifacts kept in (\S+)`).FindStringSubmatch(out)
+		if kept == nil {
+			t.Fatalf("the artifact directory was not logged:\n%s", out)
+		}
+
+		data, err := os.ReadFile(filepath.Join(kept[1], "log.txt"))
+		if err != nil || string(data) != "it broke" {
+			t.Errorf("the artifacts were not kept: %q, %v", data, err)
+		}
+	})
+
+	t.Run("of a failed subtest are kept when its parent asks for some after", func(t *testing.T) {
+		out := runFailing(t, "TestArtifacts_failingSubtest", sweet.ArtifactsEnv+"="+root)
+
+		kept := regexp.MustCompile(`sweet: artifacts kept in (\S+)`).FindAllStringSubmatch(out, -1)
+		if len(kept) != 2 {
+			t.Fatalf("expected the subtest's and the parent's directories to be logged:\n%s", out)
+		}
+
+		for _, k := range kept {
+			if _, err := os.Stat(filepath.Join(k[1], "log.txt")); err != nil {
+				t.Errorf("the artifacts in %s were not kept: %v", k[1], err)
+			}
+		}
+	})
+}
+
+func TestArtifacts_failing(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweet.Run(t, "fails", nil, func(t *testing.T, d any) {
+		if err := os.WriteFile(filepath.Join(sweet.Artifacts(t), "log.txt"), []byte("it broke"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Error("failed on purpose")
+	})
+}
+
+func TestArtifacts_failingSubtest(t *testing.T) {
+	skipUnlessFailing(t)
+
+	writeLog := func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(sweet.Artifacts(t), "log.txt"), []byte("it broke"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sweet.Run(t, "parent", nil, func(t *testing.T, d any) {
+		sweet.Run(t, "fails", nil, func(t *testing.T, d any) {
+			writeLog(t)
+			t.Error("failed on purpose")
+		})
+
+		writeLog(t)
+	})
+}
@@ -0,0 +1,106 @@
+package sweet
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet/gen"
+)
+
+// CheckSeedEnv is the environment variable setting the seed [Check]
+// generates inputs from, to replay a failing check.
+const CheckSeedEnv = "SWEET_CHECK_SEED"
+
+// ChecksEnv is the environment variable setting how many inputs [Check]
+// checks a property with. It defaults to 100.
+const ChecksEnv = "SWEET_CHECKS"
+
+const (
+	defaultChecks = 100
+	// maxShrinks caps the inputs tried while shrinking a failing one.
+	maxShrinks = 1000
+)
+
+// Check checks that prop holds for inputs generated by g, each in its own
+// subtest with fresh dependencies from factory, so one input can't leave
+// state behind for the next.
+//
+// If prop fails for an input, the input is shrunk: the smaller inputs it
+// shrinks to are tried, each with fresh dependencies, until none of them
+// fail. The smallest failing input is logged along with the seed to replay
+// the check with, by setting [CheckSeedEnv].
+//
+// It returns whether prop held for every input.
+func Check[D, T any](t *testing.T, factory DepFactory[D], g gen.Gen[T], prop func(t *testing.T, d D, in T)) bool {
+	t.Helper()
+	setTestedPackage()
+
+	seed := checkSeed()
+	r := rand.New(rand.NewSource(seed))
+
+	run := func(name string, in T) bool {
+		return t.Run(name, newTest[D](t, factory, func(t *testing.T, d D) {
+			prop(t, d, in)
+		}))
+	}
+
+	checks := checkCount()
+	for i := 0; i < checks; i++ {
+		input := g(r, i)
+		if run(fmt.Sprintf("input %d", i), input.Value) {
+			continue
+		}
+
+		minimal, shrinks := shrink(input, run)
+		t.Logf("sweet: smallest failing input, after %d shrink(s): %#v\nreplay with %s=%d", shrinks, minimal, CheckSeedEnv, seed)
+
+		return false
+	}
+
+	return true
+}
+
+// shrink tries the inputs failing shrinks to, moving on to the first that
+// fails too, until none do. It returns the last input that failed, and how
+// many times it shrank.
+func shrink[T any](failing gen.Tree[T], run func(name string, in T) bool) (T, int) {
+	tries, shrinks := 0, 0
+
+	for shrunk := true; shrunk; {
+		shrunk = false
+
+		for _, smaller := range failing.Shrinks() {
+			if tries == maxShrinks {
+				return failing.Value, shrinks
+			}
+			tries++
+
+			if !run(fmt.Sprintf("shrink %d", tries), smaller.Value) {
+				failing = smaller
+				shrinks++
+				shrunk = true
+				break
+			}
+		}
+	}
+
+	return failing.Value, shrinks
+}
+
+func checkSeed() int64 {
+	if seed, err := strconv.ParseInt(os.Getenv(CheckSeedEnv), 10, 64); err == nil {
+		return seed
+	}
+	return time.Now().UnixNano()
+}
+
+func checkCount() int {
+	if n, err := strconv.Atoi(os.Getenv(ChecksEnv)); err == nil && n > 0 {
+		return n
+	}
+	return defaultChecks
+}
@@ -0,0 +1,109 @@
+package sweet_test
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/gen"
+)
+
+type inbox struct {
+	mu     sync.Mutex
+	values []int
+	closed bool
+}
+
+func (s *inbox) add(v int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = append(s.values, v)
+}
+
+func TestCheck(t *testing.T) {
+	var inboxes []*inbox
+	factory := func(t *testing.T) *inbox {
+		s := &inbox{}
+		t.Cleanup(func() { s.closed = true })
+		inboxes = append(inboxes, s)
+		return s
+	}
+
+	ok := sweet.Check(t, factory, gen.Int(0, 100), func(t *testing.T, s *inbox, in int) {
+		if len(s.values) != 0 {
+			t.Fatalf("got an inbox another input used: %v", s.values)
+		}
+		s.add(in)
+	})
+
+	if !ok {
+		t.Error("expected the property to hold")
+	}
+	if len(inboxes) != 100 {
+		t.Errorf("expected fresh deps for each of 100 inputs, got %d", len(inboxes))
+	}
+	for _, s := range inboxes {
+		if !s.closed {
+			t.Fatal("expected the deps of every input to be cleaned up")
+		}
+	}
+}
+
+func TestCheck_checks(t *testing.T) {
+	t.Setenv(sweet.ChecksEnv, "7")
+
+	calls := 0
+	sweet.Check(t, nil, gen.Bool(), func(t *testing.T, d any, in bool) {
+		calls++
+	})
+
+	if calls != 7 {
+		t.Errorf("expected 7 inputs, got %d", calls)
+	}
+}
+
+func TestCheck_shrinks(t *testing.T) {
+	out := runFailing(t, "TestCheck_shrinksFails", sweet.CheckSeedEnv+"=42")
+
+	if !strings.Contains(out, "sweet: smallest failing input, after") ||
+		!strings.Contains(out, ": []int{50}\n") {
+		t.Errorf("expected the smallest failing input to be logged, got:\n%s", out)
+	}
+	if !strings.Contains(out, "replay with "+sweet.CheckSeedEnv+"=42") {
+		t.Errorf("expected the seed to be logged, got:\n%s", out)
+	}
+
+	// Every input tried, shrunk or not, got its own deps.
+	tried := len(regexp.MustCompile(`(?m)^\s+--- (PASS|FAIL): TestCheck_shrinksFails/`).FindAllString(out, -1))
+	built := strings.Count(out, "building deps")
+	if tried == 0 || built != tried {
+		t.Errorf("expected deps built for each of the %d inputs tried, got %d", tried, built)
+	}
+
+	again := runFailing(t, "TestCheck_shrinksFails", sweet.CheckSeedEnv+"=42")
+	if tried != len(regexp.MustCompile(`(?m)^\s+--- (PASS|FAIL): TestCheck_shrinksFails/`).FindAllString(again, -1)) {
+		t.Error("expected the same seed to replay the same inputs")
+	}
+}
+
+func TestCheck_shrinksFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	factory := func(t *testing.T) *inbox {
+		t.Log("building deps")
+		return &inbox{}
+	}
+
+	sweet.Check(t, factory, gen.SliceOf(gen.Int(0, 1000)), func(t *testing.T, s *inbox, in []int) {
+		for _, v := range in {
+			s.add(v)
+		}
+		for _, v := range s.values {
+			if v >= 50 {
+				t.Fatalf("stored %d, which is too big", v)
+			}
+		}
+	})
+}
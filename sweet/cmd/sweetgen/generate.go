@@ -0,0 +1,386 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// constructor is a function sweetgen generates a factory for.
+type constructor struct {
+	Name       string
+	Base       string
+	Result     string
+	ReturnsErr bool
+	Params     []param
+}
+
+// param is a parameter of a constructor, resolved with sweet.Resolve.
+type param struct {
+	Field string
+	Type  string
+}
+
+// source is a parsed package.
+type source struct {
+	fset    *token.FileSet
+	pkgName string
+	funcs   map[string]*ast.FuncDecl
+	files   map[*ast.FuncDecl]*ast.File
+}
+
+// parseDir parses the non test Go files of the package in dir.
+func parseDir(dir string) (*source, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &source{
+		fset:  fset,
+		funcs: map[string]*ast.FuncDecl{},
+		files: map[*ast.FuncDecl]*ast.File{},
+	}
+	for name, pkg := range pkgs {
+		if strings.HasSuffix(name, "_test") {
+			continue
+		}
+		if src.pkgName != "" {
+			return nil, fmt.Errorf("found packages %s and %s in %s", src.pkgName, name, dir)
+		}
+		src.pkgName = name
+
+		for filename, file := range pkg.Files {
+			if strings.HasSuffix(filename, "_test.go") {
+				continue
+			}
+			for _, decl := range file.Decls {
+				if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+					src.funcs[fn.Name.Name] = fn
+					src.files[fn] = file
+				}
+			}
+		}
+	}
+	if src.pkgName == "" {
+		return nil, fmt.Errorf("no Go package found in %s", dir)
+	}
+
+	return src, nil
+}
+
+// generate generates the factories for the constructors named names.
+func (src *source) generate(names []string) ([]byte, error) {
+	imports := map[string]string{}
+	var ctors []constructor
+
+	for _, name := range names {
+		fn, ok := src.funcs[name]
+		if !ok {
+			return nil, fmt.Errorf("no function %s in package %s", name, src.pkgName)
+		}
+
+		ctor, err := src.constructor(fn, imports)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		ctors = append(ctors, ctor)
+	}
+
+	b := &bytes.Buffer{}
+	err := fileTemplate.Execute(b, struct {
+		Package      string
+		Imports      []string
+		Constructors []constructor
+	}{
+		Package:      src.pkgName,
+		Imports:      importLines(imports),
+		Constructors: ctors,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, b.Bytes())
+	}
+	return out, nil
+}
+
+// constructor describes fn, adding the imports its types need to imports.
+func (src *source) constructor(fn *ast.FuncDecl, imports map[string]string) (constructor, error) {
+	if fn.Type.TypeParams != nil {
+		return constructor{}, fmt.Errorf("generic functions are not supported")
+	}
+
+	ctor := constructor{
+		Name: fn.Name.Name,
+		Base: baseName(fn.Name.Name),
+	}
+
+	results := fieldTypes(fn.Type.Results)
+	switch {
+	case len(results) == 1:
+	case len(results) == 2 && isIdent(results[1], "error"):
+		ctor.ReturnsErr = true
+	default:
+		return constructor{}, fmt.Errorf("must return a value, or a value and an error")
+	}
+	ctor.Result = src.typeString(results[0])
+
+	fields := map[string]bool{ctor.Base: true}
+	for _, field := range fn.Type.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return constructor{}, fmt.Errorf("variadic parameters are not supported")
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+		for _, name := range names {
+			fieldName := exported(name.Name)
+			if name.Name == "_" {
+				fieldName = exported(typeName(field.Type))
+			}
+			if fields[fieldName] {
+				return constructor{}, fmt.Errorf("two dependencies would be named %s", fieldName)
+			}
+			fields[fieldName] = true
+
+			ctor.Params = append(ctor.Params, param{
+				Field: fieldName,
+				Type:  src.typeString(field.Type),
+			})
+		}
+	}
+
+	file := src.files[fn]
+	for _, typ := range append([]ast.Expr{results[0]}, paramTypes(fn.Type.Params)...) {
+		if err := addImports(file, typ, imports); err != nil {
+			return constructor{}, err
+		}
+	}
+
+	return ctor, nil
+}
+
+func (src *source) typeString(expr ast.Expr) string {
+	b := &strings.Builder{}
+	printer.Fprint(b, src.fset, expr)
+	return b.String()
+}
+
+// addImports adds the imports of file that typ refers to, to imports, keyed
+// by the name typ uses for them.
+func addImports(file *ast.File, typ ast.Expr, imports map[string]string) error {
+	var err error
+	ast.Inspect(typ, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		importPath, ok := findImport(file, pkg.Name)
+		if !ok {
+			err = fmt.Errorf("cannot tell which import provides %s.%s", pkg.Name, sel.Sel.Name)
+			return false
+		}
+		imports[pkg.Name] = importPath
+		return false
+	})
+	return err
+}
+
+// majorVersion matches the major version suffix of a module path.
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// findImport returns the path of the import of file named name.
+func findImport(file *ast.File, name string) (string, bool) {
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return importPath, true
+			}
+			continue
+		}
+
+		if packageName(importPath) == name {
+			return importPath, true
+		}
+	}
+	return "", false
+}
+
+// importLines returns the import declarations for imports, the standard
+// library's first, in the groups goimports would put them in.
+func importLines(imports map[string]string) []string {
+	std := []string{strconv.Quote("testing")}
+	other := []string{strconv.Quote(sweetPath)}
+	for name, importPath := range imports {
+		if importPath == "testing" || importPath == sweetPath {
+			continue
+		}
+
+		line := strconv.Quote(importPath)
+		if packageName(importPath) != name {
+			line = name + " " + line
+		}
+
+		if strings.Contains(strings.Split(importPath, "/")[0], ".") {
+			other = append(other, line)
+		} else {
+			std = append(std, line)
+		}
+	}
+
+	// gofmt sorts each group.
+	return append(append(std, ""), other...)
+}
+
+// packageName guesses the name of the package at importPath, without type
+// information, the way goimports does.
+func packageName(importPath string) string {
+	elem := path.Base(importPath)
+	if majorVersion.MatchString(elem) {
+		elem = path.Base(path.Dir(importPath))
+	}
+	elem = strings.TrimPrefix(strings.TrimSuffix(elem, "-go"), "go-")
+	return strings.ReplaceAll(elem, "-", "")
+}
+
+// baseName names the factory and deps struct of the constructor name, e.g.
+// Service for NewService.
+func baseName(name string) string {
+	if base := strings.TrimPrefix(name, "New"); base != "" && base != name {
+		return exported(base)
+	}
+	return exported(name)
+}
+
+// initialisms are upper cased as a whole when exported.
+var initialisms = map[string]bool{
+	"api": true, "db": true, "dns": true, "http": true, "id": true,
+	"ip": true, "json": true, "sql": true, "tcp": true, "tls": true,
+	"udp": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// exported returns name as an exported identifier.
+func exported(name string) string {
+	if initialisms[strings.ToLower(name)] {
+		return strings.ToUpper(name)
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// typeName returns the name of the type typ refers to, e.g. DB for *sql.DB.
+func typeName(typ ast.Expr) string {
+	for {
+		switch t := typ.(type) {
+		case *ast.Ident:
+			return t.Name
+		case *ast.SelectorExpr:
+			return t.Sel.Name
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.ArrayType:
+			typ = t.Elt
+		default:
+			return "dep"
+		}
+	}
+}
+
+// fieldTypes returns the type of each value in fields.
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range fields.List {
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, field.Type)
+		}
+	}
+	return types
+}
+
+func paramTypes(fields *ast.FieldList) []ast.Expr {
+	var types []ast.Expr
+	for _, field := range fields.List {
+		types = append(types, field.Type)
+	}
+	return types
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	id, ok := expr.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+var fileTemplate = template.Must(template.New("sweetgen").Parse(`// Code generated by sweetgen; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+)
+{{range .Constructors}}
+// {{.Base}}Deps holds a {{.Result}} built by {{.Name}}, and what it was
+// built from.
+type {{.Base}}Deps struct {
+	{{.Base}} {{.Result}}
+{{- range .Params}}
+	{{.Field}} {{.Type}}
+{{- end}}
+}
+
+// {{.Base}}Factory is a sweet.DepFactory that builds a {{.Result}} with
+// {{.Name}}, from dependencies resolved with sweet.Resolve.
+func {{.Base}}Factory(t *testing.T) {{.Base}}Deps {
+	t.Helper()
+
+	d := {{.Base}}Deps{
+{{- range .Params}}
+		{{.Field}}: sweet.Resolve[{{.Type}}](t),
+{{- end}}
+	}
+{{if .ReturnsErr}}
+	var err error
+	d.{{.Base}}, err = {{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}d.{{$p.Field}}{{end}})
+	if err != nil {
+		t.Fatalf("{{.Name}}: %v", err)
+	}
+{{else}}
+	d.{{.Base}} = {{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}d.{{$p.Field}}{{end}})
+{{end}}
+	return d
+}
+{{end}}`))
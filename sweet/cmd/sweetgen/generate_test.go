@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/golden"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := parseDir("testdata/service")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generates := func(constructors ...string) func(t *testing.T, g *golden.Golden) {
+		return func(t *testing.T, g *golden.Golden) {
+			code, err := src.generate(constructors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.Assert(code)
+		}
+	}
+
+	sweet.Run(t, "a constructor returning an error", golden.New, generates("NewService"))
+	sweet.Run(t, "a constructor with unnamed and aliased parameters", golden.New, generates("NewWorker"))
+	sweet.Run(t, "several constructors", golden.New, generates("NewService", "NewWorker"))
+
+	for constructor, want := range map[string]string{
+		"Missing":      "no function Missing",
+		"Variadic":     "variadic parameters are not supported",
+		"Generic":      "generic functions are not supported",
+		"NoResult":     "must return a value",
+		"NewDuplicate": "two dependencies would be named Duplicate",
+	} {
+		constructor, want := constructor, want
+
+		t.Run("rejects "+constructor, func(t *testing.T) {
+			_, err := src.generate([]string{constructor})
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("got error %v, want one containing %q", err, want)
+			}
+		})
+	}
+}
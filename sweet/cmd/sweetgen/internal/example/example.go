@@ -0,0 +1,38 @@
+// Package example is built with a factory generated by sweetgen, to check the
+// code it generates compiles and works.
+package example
+
+import (
+	"time"
+
+	"github.com/barry-hennessy/test/sweet/factories/clock"
+)
+
+//go:generate go run github.com/barry-hennessy/test/sweet/cmd/sweetgen NewGreeter
+
+// Store stores greetings.
+type Store struct {
+	greetings []string
+}
+
+// Greeter greets, and remembers when.
+type Greeter struct {
+	store *Store
+	clock *clock.Clock
+}
+
+// NewGreeter creates a Greeter.
+func NewGreeter(store *Store, clk *clock.Clock) *Greeter {
+	return &Greeter{store: store, clock: clk}
+}
+
+// Greet greets name.
+func (g *Greeter) Greet(name string) {
+	g.store.greetings = append(g.store.greetings,
+		"hello "+name+" at "+g.clock.Now().Format(time.Kitchen))
+}
+
+// Greetings returns every greeting so far.
+func (s *Store) Greetings() []string {
+	return s.greetings
+}
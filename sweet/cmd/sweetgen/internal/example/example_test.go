@@ -0,0 +1,33 @@
+package example_test
+
+import (
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/cmd/sweetgen/internal/example"
+	"github.com/barry-hennessy/test/sweet/factories/clock"
+)
+
+var (
+	_ = sweet.Provide(clock.New)
+	_ = sweet.Provide(func(t *testing.T) *example.Store {
+		return &example.Store{}
+	})
+)
+
+func TestGreeterFactory(t *testing.T) {
+	sweet.Run(t, "builds from the provided factories", example.GreeterFactory, func(t *testing.T, d example.GreeterDeps) {
+		d.Greeter.Greet("sweet")
+
+		got := d.Store.Greetings()
+		if len(got) != 1 || got[0] != "hello sweet at 12:00AM" {
+			t.Errorf("unexpected greetings: %v", got)
+		}
+	})
+
+	sweet.Run(t, "builds fresh dependencies for each test", example.GreeterFactory, func(t *testing.T, d example.GreeterDeps) {
+		if got := d.Store.Greetings(); len(got) != 0 {
+			t.Errorf("expected a fresh store, got greetings: %v", got)
+		}
+	})
+}
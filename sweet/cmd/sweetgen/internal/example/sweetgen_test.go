@@ -0,0 +1,33 @@
+// Code generated by sweetgen; DO NOT EDIT.
+
+package example
+
+import (
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/clock"
+)
+
+// GreeterDeps holds a *Greeter built by NewGreeter, and what it was
+// built from.
+type GreeterDeps struct {
+	Greeter *Greeter
+	Store   *Store
+	Clk     *clock.Clock
+}
+
+// GreeterFactory is a sweet.DepFactory that builds a *Greeter with
+// NewGreeter, from dependencies resolved with sweet.Resolve.
+func GreeterFactory(t *testing.T) GreeterDeps {
+	t.Helper()
+
+	d := GreeterDeps{
+		Store: sweet.Resolve[*Store](t),
+		Clk:   sweet.Resolve[*clock.Clock](t),
+	}
+
+	d.Greeter = NewGreeter(d.Store, d.Clk)
+
+	return d
+}
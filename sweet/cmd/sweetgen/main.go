@@ -0,0 +1,60 @@
+// Command sweetgen generates sweet dependency factories from constructors.
+//
+// For a constructor like:
+//
+//	func NewService(db *sql.DB, cache *redis.Client) (*Service, error)
+//
+// it generates a ServiceDeps struct, holding the *Service along with the
+// *sql.DB and *redis.Client it was built from, and a ServiceFactory
+// sweet.DepFactory that builds them. Each parameter is built with
+// sweet.Resolve, by the factory registered for its type with sweet.Provide,
+// so adding a parameter to the constructor only means running go generate.
+//
+// Use it with go generate, in the constructor's package:
+//
+//	//go:generate go run github.com/barry-hennessy/test/sweet/cmd/sweetgen NewService
+//
+// The factories are written to sweetgen_test.go unless -o says otherwise.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+const sweetPath = "github.com/barry-hennessy/test/sweet"
+
+func main() {
+	out := flag.String("o", "sweetgen_test.go", "the file to write the factories to")
+	dir := flag.String("dir", ".", "the directory of the package the constructors are in")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: sweetgen [-o file] [-dir dir] Constructor...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*dir, *out, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "sweetgen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir, out string, constructors []string) error {
+	src, err := parseDir(dir)
+	if err != nil {
+		return err
+	}
+
+	code, err := src.generate(constructors)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(out, code, 0o644)
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	stdlog "log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Service struct{}
+
+func NewService(db *sql.DB, cache *redis.Client) (*Service, error) {
+	if db == nil {
+		return nil, errors.New("no database")
+	}
+	return &Service{}, nil
+}
+
+type Worker struct{}
+
+func NewWorker(_ *stdlog.Logger, s *Service, retries int) Worker {
+	return Worker{}
+}
+
+func Variadic(s ...*Service) *Service {
+	return nil
+}
+
+func Generic[T any](t T) *Service {
+	return nil
+}
+
+func NoResult(s *Service) {}
+
+func NewDuplicate(duplicate *Service) *Service {
+	return nil
+}
@@ -0,0 +1,66 @@
+// Command sweetjunit converts the output of go test -json, along with the
+// events sweet writes to SWEET_EVENTS, to a JUnit XML report. Tests that
+// failed setting up their dependencies are reported as errors rather than
+// failures. See the junit package for the details.
+//
+//	SWEET_EVENTS=$PWD/events.json go test -json ./... | sweetjunit -events events.json > report.xml
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/barry-hennessy/test/sweet/junit"
+)
+
+func main() {
+	events := flag.String("events", "", "the file sweet wrote its events to, through SWEET_EVENTS")
+	out := flag.String("o", "", "the file to write the report to, instead of stdout")
+	flag.Parse()
+
+	if err := run(os.Stdin, *events, *out); err != nil {
+		fmt.Fprintf(os.Stderr, "sweetjunit: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(goTest io.Reader, eventsFile, outFile string) error {
+	// Piped from go test, the events are only all written once its output
+	// has all been read.
+	output, err := io.ReadAll(goTest)
+	if err != nil {
+		return err
+	}
+
+	var events io.Reader
+	if eventsFile != "" {
+		f, err := os.Open(eventsFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		events = f
+	}
+
+	suites, err := junit.Convert(bytes.NewReader(output), events)
+	if err != nil {
+		return err
+	}
+
+	if outFile == "" {
+		return suites.Write(os.Stdout)
+	}
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	if err := suites.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
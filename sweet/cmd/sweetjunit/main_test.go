@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/junit"
+)
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test")
+	}
+
+	dir := t.TempDir()
+	events := filepath.Join(dir, "events.json")
+	report := filepath.Join(dir, "report.xml")
+
+	// An earlier run, where the test that fails now failed setting up,
+	// left its events in the file.
+	stale := `{"Time":"2026-01-01T00:00:00Z","Action":"factory-end","Package":"github.com/barry-hennessy/test/sweet/cmd/sweetjunit/testdata/example","Test":"TestExample/fails","Elapsed":3,"Failed":true,"Run":"1-1"}` + "\n"
+	if err := os.WriteFile(events, []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("go", "test", "-json", "-count=1", "./testdata/example")
+	cmd.Env = append(os.Environ(), sweet.EventsEnv+"="+events)
+	goTest, err := cmd.Output()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("expected the example tests to fail, got %v:\n%s", err, goTest)
+	}
+
+	if err := run(bytes.NewReader(goTest), events, report); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suites := &junit.Suites{}
+	if err := xml.Unmarshal(data, suites); err != nil {
+		t.Fatalf("decoding the report: %s\n%s", err, data)
+	}
+
+	if len(suites.Suites) != 1 {
+		t.Fatalf("expected a suite for the example package, got:\n%s", data)
+	}
+	cases := map[string]*junit.Case{}
+	for _, c := range suites.Suites[0].Cases {
+		cases[c.Name] = c
+	}
+
+	if c := cases["TestExample/passes"]; c == nil || c.Failure != nil || c.Error != nil || c.Properties == nil {
+		t.Errorf("expected TestExample/passes to pass, with its setup and cleanup times, got:\n%s", data)
+	}
+	if c := cases["TestExample/fails_setting_up"]; c == nil || c.Error == nil {
+		t.Errorf("expected TestExample/fails_setting_up to be reported as an error, got:\n%s", data)
+	}
+	if c := cases["TestExample/fails"]; c == nil || c.Failure == nil || c.Error != nil {
+		t.Errorf("expected TestExample/fails to be reported as a failure, not going by the earlier run, got:\n%s", data)
+	}
+}
@@ -0,0 +1,24 @@
+package example_test
+
+import (
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+func newDB(t *testing.T) string {
+	return "db"
+}
+
+func TestExample(t *testing.T) {
+	sweet.Run(t, "passes", newDB, func(t *testing.T, db string) {})
+
+	sweet.Run(t, "fails setting up", func(t *testing.T) string {
+		t.Fatal("starting container: connection refused")
+		return ""
+	}, func(t *testing.T, db string) {})
+
+	sweet.Run(t, "fails", newDB, func(t *testing.T, db string) {
+		t.Error("got no rows")
+	})
+}
@@ -0,0 +1,82 @@
+package sweet
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// contexts holds the context of each test that asked for one.
+var contexts sync.Map
+
+type testContext struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Context returns a context for t, to use in place of [context.Background] in
+// factories and tests. It has t's deadline, if it has one, and is cancelled
+// when the test ends.
+//
+// For tests run with [Run] it is cancelled once the test body returns and any
+// [OnFailure] hooks have run, before the cleanups; like [context.Context]s
+// passed to a request handler, it must not be used by cleanups. For other
+// tests it is cancelled as a cleanup.
+func Context(t *testing.T) context.Context {
+	v, ok := contexts.Load(t)
+	if !ok {
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+		if deadline, ok := t.Deadline(); ok {
+			ctx, cancel = context.WithDeadline(context.Background(), deadline)
+		} else {
+			ctx, cancel = context.WithCancel(context.Background())
+		}
+
+		var loaded bool
+		v, loaded = contexts.LoadOrStore(t, &testContext{ctx: ctx, cancel: cancel})
+		if loaded {
+			cancel()
+		} else {
+			t.Cleanup(func() {
+				cancelContext(t)
+			})
+		}
+	}
+
+	tc := v.(*testContext)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	return tc.ctx
+}
+
+// setContext replaces the context [Context] returns for t with ctx, until the
+// returned function is called to restore it.
+func setContext(t *testing.T, ctx context.Context) (restore func()) {
+	Context(t)
+
+	v, _ := contexts.Load(t)
+	tc := v.(*testContext)
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	previous := tc.ctx
+	tc.ctx = ctx
+
+	return func() {
+		tc.mu.Lock()
+		defer tc.mu.Unlock()
+		tc.ctx = previous
+	}
+}
+
+// cancelContext cancels t's context, if it has one.
+func cancelContext(t *testing.T) {
+	if v, ok := contexts.LoadAndDelete(t); ok {
+		v.(*testContext).cancel()
+	}
+}
@@ -0,0 +1,42 @@
+package sweet_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+func TestContext(t *testing.T) {
+	var ctx context.Context
+	var errDuringCleanup error
+
+	factory := func(t *testing.T) any {
+		t.Cleanup(func() {
+			errDuringCleanup = ctx.Err()
+		})
+		return nil
+	}
+
+	sweet.Run(t, "test", factory, func(t *testing.T, d any) {
+		ctx = sweet.Context(t)
+
+		if ctx.Err() != nil {
+			t.Errorf("the context is done during the test: %s", ctx.Err())
+		}
+
+		if sweet.Context(t) != ctx {
+			t.Error("a second call gave a different context")
+		}
+
+		if _, ok := t.Deadline(); ok {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("the context does not have the test's deadline")
+			}
+		}
+	})
+
+	if errDuringCleanup != context.Canceled {
+		t.Errorf("the context was not cancelled before the cleanups ran: %v", errDuringCleanup)
+	}
+}
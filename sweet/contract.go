@@ -0,0 +1,107 @@
+package sweet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Contract is a suite of test cases that every implementation of an
+// interface should pass, e.g. an in memory store and a Postgres store
+// behaving the same way.
+//
+// Define a contract once, next to the interface, and run it against each
+// implementation's [DepFactory].
+type Contract[I any] map[string]func(t *testing.T, impl I)
+
+// Run runs every clause of the contract against every implementation, as
+// subtests named "<implementation>/<clause>". Both run in name order.
+//
+// Each clause gets a fresh value from the implementation's factory. Clauses
+// that call t.Parallel run alongside the other clauses of their
+// implementation, and are waited for like the rest. If any clause fails, a
+// summary of which implementation broke which clauses is logged once all have
+// run. Run reports whether every implementation kept to the contract.
+func (c Contract[I]) Run(t *testing.T, impls map[string]DepFactory[I]) bool {
+	t.Helper()
+
+	violations := &contractViolations{byImpl: map[string][]string{}}
+
+	for _, implName := range sortedKeys(impls) {
+		factory := impls[implName]
+		implName := implName
+
+		t.Run(implName, func(t *testing.T) {
+			for _, clauseName := range sortedKeys(c) {
+				clauseName := clauseName
+
+				// The cleanup is registered before the factory's, so it
+				// runs last, once the clause and its teardown are done.
+				watched := func(t *testing.T) I {
+					t.Cleanup(func() {
+						if t.Failed() {
+							violations.add(implName, clauseName)
+						}
+					})
+					return factory(t)
+				}
+
+				Run(t, clauseName, watched, c[clauseName])
+			}
+		})
+	}
+
+	summary := violations.summary()
+	if summary == "" {
+		return true
+	}
+
+	t.Log(summary)
+	return false
+}
+
+// contractViolations are the clauses each implementation broke. Clauses add
+// to them as they end, which may be in parallel.
+type contractViolations struct {
+	mu     sync.Mutex
+	byImpl map[string][]string
+}
+
+func (v *contractViolations) add(implName, clauseName string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.byImpl[implName] = append(v.byImpl[implName], clauseName)
+}
+
+// summary describes the violations so far, or returns "" if there are none.
+func (v *contractViolations) summary() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.byImpl) == 0 {
+		return ""
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("contract violations:")
+	for _, implName := range sortedKeys(v.byImpl) {
+		clauses := append([]string{}, v.byImpl[implName]...)
+		sort.Strings(clauses)
+		fmt.Fprintf(b, "\n\t%s: %s", implName, strings.Join(clauses, ", "))
+	}
+
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,157 @@
+package sweet_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type store interface {
+	Put(key, value string)
+	Get(key string) (string, bool)
+}
+
+type mapStore map[string]string
+
+func (m mapStore) Put(key, value string) {
+	m[key] = value
+}
+
+func (m mapStore) Get(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+type sliceStore struct {
+	keys, values []string
+}
+
+func (s *sliceStore) Put(key, value string) {
+	s.keys = append(s.keys, key)
+	s.values = append(s.values, value)
+}
+
+func (s *sliceStore) Get(key string) (string, bool) {
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if s.keys[i] == key {
+			return s.values[i], true
+		}
+	}
+
+	return "", false
+}
+
+var storeContract = sweet.Contract[store]{
+	"gets what was put": func(t *testing.T, s store) {
+		s.Put("a", "1")
+
+		if v, ok := s.Get("a"); !ok || v != "1" {
+			t.Errorf("expected 1, got %q", v)
+		}
+	},
+	"starts empty": func(t *testing.T, s store) {
+		if _, ok := s.Get("a"); ok {
+			t.Error("the store is not empty")
+		}
+	},
+	"overwrites": func(t *testing.T, s store) {
+		s.Put("a", "1")
+		s.Put("a", "2")
+
+		if v, _ := s.Get("a"); v != "2" {
+			t.Errorf("expected 2, got %q", v)
+		}
+	},
+}
+
+func TestContract(t *testing.T) {
+	ran := map[string]int{}
+
+	counted := func(name string, factory sweet.DepFactory[store]) sweet.DepFactory[store] {
+		return func(t *testing.T) store {
+			ran[name]++
+			return factory(t)
+		}
+	}
+
+	passed := storeContract.Run(t, map[string]sweet.DepFactory[store]{
+		"map": counted("map", func(t *testing.T) store {
+			return mapStore{}
+		}),
+		"slice": counted("slice", func(t *testing.T) store {
+			return &sliceStore{}
+		}),
+	})
+
+	if !passed {
+		t.Error("the contract was not kept")
+	}
+
+	for _, impl := range []string{"map", "slice"} {
+		if ran[impl] != len(storeContract) {
+			t.Errorf("expected a fresh %s store for each of the %d clauses, got %d", impl, len(storeContract), ran[impl])
+		}
+	}
+}
+
+// leakyStore forgets what was overwritten, breaking the contract.
+type leakyStore struct {
+	mapStore
+}
+
+func (l leakyStore) Put(key, value string) {
+	if _, ok := l.mapStore[key]; ok {
+		return
+	}
+	l.mapStore.Put(key, value)
+}
+
+func TestContract_violations(t *testing.T) {
+	for _, test := range []string{"TestContract_violationsFails", "TestContract_parallelViolationsFails"} {
+		out := runFailing(t, test)
+
+		for _, want := range []string{"contract violations:", "leaky: overwrites"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: expected the output to contain %q, got:\n%s", test, want, out)
+			}
+		}
+		if strings.Contains(out, "map: ") {
+			t.Errorf("%s: expected the map store to keep to the contract, got:\n%s", test, out)
+		}
+	}
+}
+
+var contractImpls = map[string]sweet.DepFactory[store]{
+	"map": func(t *testing.T) store {
+		return mapStore{}
+	},
+	"leaky": func(t *testing.T) store {
+		return leakyStore{mapStore{}}
+	},
+}
+
+func TestContract_violationsFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	if storeContract.Run(t, contractImpls) {
+		t.Error("expected the contract to be broken")
+	}
+}
+
+func TestContract_parallelViolationsFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	parallel := sweet.Contract[store]{}
+	for name, clause := range storeContract {
+		clause := clause
+		parallel[name] = func(t *testing.T, s store) {
+			t.Parallel()
+			clause(t, s)
+		}
+	}
+
+	if parallel.Run(t, contractImpls) {
+		t.Error("expected the contract to be broken")
+	}
+}
@@ -0,0 +1,72 @@
+package sweet
+
+import (
+	"sync"
+	"testing"
+)
+
+// diagnostics holds the failure hooks registered for each test.
+var diagnostics sync.Map
+
+type failureHooks struct {
+	mu    sync.Mutex
+	hooks []func(t *testing.T)
+	ran   bool
+}
+
+// OnFailure registers hook to be called if t fails, e.g. to log the state of
+// a dependency: rows in a database, a container's logs or the requests a
+// server received.
+//
+// For tests run with [Run] hooks are called once the test body returns, after
+// any [Verifier] and before the cleanups tear the dependencies down. Their
+// output is grouped under a "dependency diagnostics" heading. For other tests
+// they are called as a cleanup.
+//
+// Hooks run in the order they were registered.
+func OnFailure(t *testing.T, hook func(t *testing.T)) {
+	v, loaded := diagnostics.LoadOrStore(t, &failureHooks{})
+	fh := v.(*failureHooks)
+
+	if !loaded {
+		t.Cleanup(func() {
+			runDiagnostics(t)
+			diagnostics.Delete(t)
+		})
+	}
+
+	fh.mu.Lock()
+	defer fh.mu.Unlock()
+	fh.hooks = append(fh.hooks, hook)
+}
+
+// runDiagnostics calls the failure hooks registered for t, if t has failed.
+// They are only ever called once.
+func runDiagnostics(t *testing.T) {
+	if !t.Failed() {
+		return
+	}
+
+	v, ok := diagnostics.Load(t)
+	if !ok {
+		return
+	}
+	fh := v.(*failureHooks)
+
+	fh.mu.Lock()
+	if fh.ran || len(fh.hooks) == 0 {
+		fh.mu.Unlock()
+		return
+	}
+	fh.ran = true
+	hooks := append([]func(t *testing.T){}, fh.hooks...)
+	fh.mu.Unlock()
+
+	inStep(t, ActionDiagnosticsStart, ActionDiagnosticsEnd, func() {
+		t.Log("sweet: dependency diagnostics")
+		for _, hook := range hooks {
+			hook(t)
+		}
+		t.Log("sweet: end of dependency diagnostics")
+	})
+}
@@ -0,0 +1,60 @@
+package sweet_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type diagnosedDeps struct {
+	state string
+}
+
+func diagnosedFactory(t *testing.T) *diagnosedDeps {
+	d := &diagnosedDeps{state: "running"}
+
+	sweet.OnFailure(t, func(t *testing.T) {
+		t.Logf("the dependency was %s", d.state)
+	})
+
+	t.Cleanup(func() {
+		d.state = "cleaned up"
+	})
+
+	return d
+}
+
+func TestOnFailure(t *testing.T) {
+	t.Run("hooks are not called when the test passes", func(t *testing.T) {
+		called := false
+
+		sweet.Run(t, "passes", func(t *testing.T) any {
+			sweet.OnFailure(t, func(t *testing.T) {
+				called = true
+			})
+			return nil
+		}, func(t *testing.T, d any) {})
+
+		if called {
+			t.Error("the hook was called")
+		}
+	})
+
+	t.Run("hooks are called before cleanup when the test fails", func(t *testing.T) {
+		out := runFailing(t, "TestOnFailure_failing")
+
+		expected := regexp.MustCompile(`(?s)sweet: dependency diagnostics.*the dependency was running.*sweet: end of dependency diagnostics`)
+		if !expected.MatchString(out) {
+			t.Errorf("the diagnostics were not logged:\n%s", out)
+		}
+	})
+}
+
+func TestOnFailure_failing(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweet.Run(t, "fails", diagnosedFactory, func(t *testing.T, d *diagnosedDeps) {
+		t.Fatal("failed on purpose")
+	})
+}
@@ -0,0 +1,234 @@
+package sweet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// EventsEnv is the environment variable naming a file to append [Event]s to.
+// Every test binary run appends to it, so events of earlier runs are kept
+// alongside; their Run tells them apart.
+const EventsEnv = "SWEET_EVENTS"
+
+// The actions of [Event]s.
+const (
+	ActionTestStart        = "test-start"
+	ActionTestEnd          = "test-end"
+	ActionFactoryStart     = "factory-start"
+	ActionFactoryEnd       = "factory-end"
+	ActionBodyStart        = "body-start"
+	ActionBodyEnd          = "body-end"
+	ActionCleanupStart     = "cleanup-start"
+	ActionCleanupEnd       = "cleanup-end"
+	ActionDiagnosticsStart = "diagnostics-start"
+	ActionDiagnosticsEnd   = "diagnostics-end"
+)
+
+// Event is a step in the life of a test run with [Run] starting or ending:
+// the test itself, its [DepFactory] building its dependencies, the test body,
+// its cleanups tearing them down and its [OnFailure] hooks running.
+//
+// Events are written as JSON, one per line, to the file named by [EventsEnv]
+// or the writer set with [SetEventWriter]. Their Package and Test match the
+// output of go test -json, so the two can be joined.
+type Event struct {
+	Time   time.Time
+	Action string
+	// Package is the import path of the package being tested.
+	Package string `json:",omitempty"`
+	// Test is the full name of the test, as [testing.T.Name] returns it.
+	Test string
+	// Elapsed is how long the step took, in seconds, on the events ending
+	// a step.
+	Elapsed float64 `json:",omitempty"`
+	// Failed reports, on the events ending a step, whether the test had
+	// failed by the end of it.
+	Failed bool `json:",omitempty"`
+	// Run identifies the run of the test binary writing the event, so the
+	// events of separate runs appended to the same file can be told apart.
+	Run string `json:",omitempty"`
+}
+
+// runID identifies this run of the test binary in its events.
+var runID = fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+
+var events struct {
+	mu     sync.Mutex
+	w      io.Writer
+	opened bool
+}
+
+// SetEventWriter sets the writer [Event]s are written to, in place of the
+// file named by [EventsEnv]. A nil writer stops events being written.
+func SetEventWriter(w io.Writer) {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	events.w = w
+	events.opened = true
+}
+
+// newEvent creates an event for t. Events ending a step, begun at start,
+// carry how long it took and whether t has failed.
+func newEvent(t *testing.T, action string, start time.Time) Event {
+	ev := Event{
+		Time:    time.Now(),
+		Action:  action,
+		Package: testedPackage(),
+		Test:    t.Name(),
+		Run:     runID,
+	}
+	if !start.IsZero() {
+		ev.Elapsed = ev.Time.Sub(start).Seconds()
+		ev.Failed = t.Failed()
+	}
+	return ev
+}
+
+// writeEvent writes ev, if events are being written.
+func writeEvent(ev Event) {
+	events.mu.Lock()
+	defer events.mu.Unlock()
+
+	if !events.opened {
+		events.opened = true
+		events.w = openEvents()
+	}
+	if events.w == nil {
+		return
+	}
+
+	line, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	// One write per event keeps events from several test binaries,
+	// appending to the same file, whole.
+	events.w.Write(append(line, '\n'))
+}
+
+// openEvents opens the file named by EventsEnv, if it is set.
+func openEvents() io.Writer {
+	name := os.Getenv(EventsEnv)
+	if name == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sweet: opening %s: %s\n", EventsEnv, err)
+		return nil
+	}
+	return f
+}
+
+// startEvents starts the test step of t, nested in parent, and arranges for
+// it and the cleanup step to end. [Run] defers the function it returns, to
+// start the cleanup step.
+func startEvents(t, parent *testing.T) (cleanupsStarting func()) {
+	if observing() {
+		inheritValues(t, parent)
+	}
+
+	test := startStep(t, ActionTestStart, nil)
+	// t's Context carries the test step's until it is cancelled.
+	test.restore = nil
+
+	var cleanup *step
+	t.Cleanup(func() {
+		cleanup.end(ActionCleanupEnd)
+		test.end(ActionTestEnd)
+	})
+
+	return func() {
+		// Registered last, so it runs first.
+		t.Cleanup(func() {
+			cleanup = startStep(t, ActionCleanupStart, test.ctx)
+		})
+	}
+}
+
+// inStep calls f as a step of t, between the start and end actions.
+func inStep(t *testing.T, start, end string, f func()) {
+	s := startStep(t, start, nil)
+	defer s.end(end)
+
+	f()
+}
+
+// build calls factory in the factory step of t.
+func build[D any](t *testing.T, factory DepFactory[D]) D {
+	var d D
+	inStep(t, ActionFactoryStart, ActionFactoryEnd, func() {
+		d = factory(t)
+	})
+	return d
+}
+
+var tested struct {
+	once sync.Once
+	pkg  string
+}
+
+// setTestedPackage records the package being tested: the one the test binary
+// was built for, e.g. github.com/x/y for github.com/x/y.test, or failing that
+// the package of the test function calling it. The test bodies passed to [Run]
+// don't say, they may come from a helper package such as sweettest. Only the
+// first call counts, there is one per test binary.
+func setTestedPackage() {
+	tested.once.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok && strings.HasSuffix(info.Path, ".test") {
+			tested.pkg = strings.TrimSuffix(info.Path, ".test")
+			return
+		}
+
+		pcs := make([]uintptr, 64)
+		frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+		for more := true; more; {
+			var f runtime.Frame
+			f, more = frames.Next()
+			if isTestFunc(f.Function) {
+				tested.pkg = packageOf(f.Function)
+				return
+			}
+		}
+	})
+}
+
+func testedPackage() string {
+	return tested.pkg
+}
+
+// isTestFunc reports whether the function named name is a test, e.g.
+// github.com/x/y_test.TestZ, or a function literal in one.
+func isTestFunc(name string) bool {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.HasPrefix(name, "Test")
+}
+
+// packageOf returns the package of the function named name, e.g.
+// github.com/x/y for github.com/x/y_test.TestZ.func1. External test packages
+// belong to the package they test, as they do in go test -json.
+func packageOf(name string) string {
+	dir := ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		dir, name = name[:i+1], name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return dir + strings.TrimSuffix(name, "_test")
+}
@@ -0,0 +1,188 @@
+package sweet_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+func TestEvents(t *testing.T) {
+	b := &bytes.Buffer{}
+	sweet.SetEventWriter(b)
+	t.Cleanup(func() {
+		sweet.SetEventWriter(nil)
+	})
+
+	factory := func(t *testing.T) int {
+		t.Cleanup(func() {})
+		return 1
+	}
+	sweet.Run(t, "passes", factory, func(t *testing.T, d int) {})
+
+	events := decodeEvents(t, b.Bytes())
+
+	wantActions := []string{
+		sweet.ActionTestStart,
+		sweet.ActionFactoryStart,
+		sweet.ActionFactoryEnd,
+		sweet.ActionBodyStart,
+		sweet.ActionBodyEnd,
+		sweet.ActionCleanupStart,
+		sweet.ActionCleanupEnd,
+		sweet.ActionTestEnd,
+	}
+	if got := actions(events); !reflect.DeepEqual(got, wantActions) {
+		t.Fatalf("got actions %v, want %v", got, wantActions)
+	}
+
+	for _, ev := range events {
+		if ev.Test != "TestEvents/passes" {
+			t.Errorf("%s: got test %q, want the name go test -json uses", ev.Action, ev.Test)
+		}
+		if ev.Package != "github.com/barry-hennessy/test/sweet" {
+			t.Errorf("%s: got package %q, want the package under test", ev.Action, ev.Package)
+		}
+		if ev.Failed {
+			t.Errorf("%s: marked as failed", ev.Action)
+		}
+		if ev.Time.IsZero() {
+			t.Errorf("%s: has no time", ev.Action)
+		}
+		if ev.Run == "" || ev.Run != events[0].Run {
+			t.Errorf("%s: got run %q, want every event to have the run of the first, %q", ev.Action, ev.Run, events[0].Run)
+		}
+	}
+}
+
+func TestEvents_failing(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.json")
+	runFailing(t, "TestEvents_failingFails", sweet.EventsEnv+"="+file)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := decodeEvents(t, data)
+
+	wantActions := []string{
+		sweet.ActionTestStart,
+		sweet.ActionFactoryStart,
+		sweet.ActionFactoryEnd,
+		sweet.ActionBodyStart,
+		sweet.ActionBodyEnd,
+		sweet.ActionDiagnosticsStart,
+		sweet.ActionDiagnosticsEnd,
+		sweet.ActionCleanupStart,
+		sweet.ActionCleanupEnd,
+		sweet.ActionTestEnd,
+	}
+	if got := actions(events); !reflect.DeepEqual(got, wantActions) {
+		t.Fatalf("got actions %v, want %v", got, wantActions)
+	}
+
+	for _, ev := range events {
+		switch ev.Action {
+		case sweet.ActionFactoryEnd:
+			if ev.Failed {
+				t.Error("the factory was marked as failing the test")
+			}
+		case sweet.ActionBodyEnd, sweet.ActionDiagnosticsEnd, sweet.ActionCleanupEnd, sweet.ActionTestEnd:
+			if !ev.Failed {
+				t.Errorf("%s: not marked as failed", ev.Action)
+			}
+		}
+	}
+}
+
+func TestEvents_runs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.json")
+	runFailing(t, "TestEvents_failingFails", sweet.EventsEnv+"="+file)
+	runFailing(t, "TestEvents_failingFails", sweet.EventsEnv+"="+file)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := decodeEvents(t, data)
+
+	runs := map[string]int{}
+	for _, ev := range events {
+		runs[ev.Run]++
+	}
+	if len(runs) != 2 || runs[events[0].Run] != len(events)/2 {
+		t.Errorf("expected the events of each run to be appended with their own run, got %v", runs)
+	}
+}
+
+func TestEvents_failingFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	factory := func(t *testing.T) int {
+		sweet.OnFailure(t, func(t *testing.T) {})
+		return 1
+	}
+	sweet.Run(t, "fails", factory, func(t *testing.T, d int) {
+		t.Error("failing on purpose")
+	})
+}
+
+func TestEvents_helperPackage(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "events.json")
+	runFailing(t, "TestEvents_helperPackageFails", sweet.EventsEnv+"="+file)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := decodeEvents(t, data)
+	if len(events) == 0 {
+		t.Fatal("expected events to have been written")
+	}
+	for _, ev := range events {
+		if ev.Package != "github.com/barry-hennessy/test/sweet" {
+			t.Fatalf("%s %s: got package %q, want the package under test rather than the helper's", ev.Action, ev.Test, ev.Package)
+		}
+	}
+}
+
+func TestEvents_helperPackageFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	// The first test bodies run are sweettest's, so they can't tell which
+	// package is being tested.
+	shared := &struct{ n int }{}
+	sweettest.CheckFactory(t, func(t *testing.T) *struct{ n int } {
+		return shared
+	}, sweettest.Options[*struct{ n int }]{})
+}
+
+func decodeEvents(t *testing.T, data []byte) []sweet.Event {
+	t.Helper()
+
+	var events []sweet.Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var ev sweet.Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("decoding %q: %s", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+func actions(events []sweet.Event) []string {
+	var got []string
+	for _, ev := range events {
+		got = append(got, ev.Action)
+	}
+	return got
+}
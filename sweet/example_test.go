@@ -301,3 +301,29 @@ func ExampleRun_pitfallNestingAlternative() {
 		})
 	})
 }
+
+// When an interface has more than one implementation, e.g. a mock and the real
+// thing, a [sweet.Contract] holds the tests they should all pass.
+//
+// Each clause runs against a fresh value from every implementation's factory,
+// and a summary of who broke which clause is logged if any fail.
+func ExampleContract() {
+	t := &testing.T{}
+
+	hoseContract := sweet.Contract[hose]{
+		"comes reeled up": func(t *testing.T, h hose) {
+			if !h.IsReeledUp() {
+				t.Error("the hose was left dangling")
+			}
+		},
+	}
+
+	hoseContract.Run(t, map[string]sweet.DepFactory[hose]{
+		"mock": func(t *testing.T) hose {
+			return mockHose{}
+		},
+		"fire truck": func(t *testing.T) hose {
+			return fireTruck{hose: mockHose{}}.hose
+		},
+	})
+}
@@ -0,0 +1,213 @@
+// Package clock provides a controllable clock for tests of time based code.
+//
+// The clock only moves when the test tells it to, with [Clock.Advance]; timers,
+// tickers and AfterFunc callbacks fire as it does. Code under test should take
+// its time from an interface the [Clock] satisfies instead of calling
+// [time.Now] directly.
+package clock
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// Epoch is the time a clock created by [New] starts at.
+var Epoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// clocks holds the clock of each test that asked for one, so every factory in
+// a composed set of dependencies shares the same clock.
+var clocks sync.Map
+
+// Clock is a fake clock. It is safe for concurrent use.
+type Clock struct {
+	mu           sync.Mutex
+	now          time.Time
+	timers       []*timer
+	allowPending bool
+}
+
+type timer struct {
+	when    time.Time
+	period  time.Duration
+	c       chan time.Time
+	f       func()
+	active  bool
+	created string
+}
+
+// New is a [sweet.DepFactory] for a clock starting at [Epoch].
+//
+// Every call with the same t returns the same clock, so the factories of a
+// composed dependency struct can each ask for one and share it.
+//
+// When the test ends it fails if any timer is still waiting to fire; see
+// [Clock.AllowPending].
+func New(t *testing.T) *Clock {
+	return NewFactory(Epoch)(t)
+}
+
+// NewFactory creates a [sweet.DepFactory] for clocks starting at start.
+//
+// As with [New], all calls for the same test share one clock. The first call
+// decides the start time.
+func NewFactory(start time.Time) sweet.DepFactory[*Clock] {
+	return func(t *testing.T) *Clock {
+		// Factories composed with sweet.Parallel ask for it at once.
+		v, loaded := clocks.LoadOrStore(t, &Clock{now: start})
+		c := v.(*Clock)
+		if loaded {
+			return c
+		}
+
+		t.Cleanup(func() {
+			clocks.Delete(t)
+			c.checkPending(t)
+		})
+
+		return c
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Since returns the time elapsed on the clock since u.
+func (c *Clock) Since(u time.Time) time.Duration {
+	return c.Now().Sub(u)
+}
+
+// Until returns the duration on the clock until u.
+func (c *Clock) Until(u time.Time) time.Duration {
+	return u.Sub(c.Now())
+}
+
+// Advance moves the clock forward by d, firing every timer and ticker that
+// falls due on the way in order.
+//
+// Timer and ticker channels are sent the time they fell due at, dropping the
+// value if the channel is full just like [time.Timer]. AfterFunc callbacks run
+// on the calling goroutine before Advance returns, so tests can rely on them
+// having happened.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+
+	for {
+		next := c.next(target)
+		if next == nil {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+
+		c.now = next.when
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			next.active = false
+		}
+
+		if next.f != nil {
+			c.mu.Unlock()
+			next.f()
+			c.mu.Lock()
+			continue
+		}
+
+		select {
+		case next.c <- c.now:
+		default:
+		}
+	}
+}
+
+// AllowPending stops the clock failing the test when timers are still
+// pending at the end of it.
+func (c *Clock) AllowPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.allowPending = true
+}
+
+// next returns the earliest active timer due at or before target.
+func (c *Clock) next(target time.Time) *timer {
+	var next *timer
+	for _, tm := range c.timers {
+		if !tm.active || tm.when.After(target) {
+			continue
+		}
+
+		if next == nil || tm.when.Before(next.when) {
+			next = tm
+		}
+	}
+
+	return next
+}
+
+func (c *Clock) add(d, period time.Duration, f func()) *timer {
+	tm := &timer{
+		period:  period,
+		f:       f,
+		active:  true,
+		created: caller(),
+	}
+	if f == nil {
+		tm.c = make(chan time.Time, 1)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tm.when = c.now.Add(d)
+	c.timers = append(c.timers, tm)
+
+	return tm
+}
+
+// checkPending fails t for every timer that never fired and was never stopped.
+// Tickers are exempt; they are expected to keep ticking.
+func (c *Clock) checkPending(t testing.TB) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.allowPending {
+		return
+	}
+
+	pending := []string{}
+	for _, tm := range c.timers {
+		if tm.active && tm.period == 0 {
+			pending = append(pending, fmt.Sprintf("due at %s, created at %s", tm.when.Format(time.RFC3339Nano), tm.created))
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	sort.Strings(pending)
+	t.Errorf("clock: %d timer(s) never fired:\n\t%s", len(pending), strings.Join(pending, "\n\t"))
+}
+
+// caller returns the file and line that called into the clock.
+func caller() string {
+	_, file, line, ok := runtime.Caller(3)
+	if !ok {
+		return "unknown"
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
@@ -0,0 +1,43 @@
+package clock
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet/internal/recorder"
+)
+
+func TestCheckPending(t *testing.T) {
+	t.Run("reports timers that never fired", func(t *testing.T) {
+		c := &Clock{now: Epoch}
+		c.NewTimer(time.Second)
+		c.AfterFunc(time.Minute, func() {})
+
+		r := &recorder.TB{}
+		c.checkPending(r)
+
+		if len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "2 timer(s) never fired") {
+			t.Errorf("unexpected errors: %q", r.Errors)
+		}
+
+		if !strings.Contains(r.Errors[0], "clock_internal_test.go") {
+			t.Errorf("the error does not say where the timers were created: %q", r.Errors[0])
+		}
+	})
+
+	t.Run("ignores fired, stopped timers and tickers", func(t *testing.T) {
+		c := &Clock{now: Epoch}
+		c.NewTimer(time.Second)
+		c.NewTimer(time.Hour).Stop()
+		c.NewTicker(time.Second)
+		c.Advance(time.Second)
+
+		r := &recorder.TB{}
+		c.checkPending(r)
+
+		if len(r.Errors) != 0 {
+			t.Errorf("unexpected errors: %q", r.Errors)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/clock"
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+type scheduler struct {
+	clock *clock.Clock
+}
+
+type composedDeps struct {
+	clock     *clock.Clock
+	scheduler scheduler
+}
+
+func TestClock(t *testing.T) {
+	sweet.Run(t, "starts at the epoch", clock.New, func(t *testing.T, c *clock.Clock) {
+		if !c.Now().Equal(clock.Epoch) {
+			t.Errorf("expected %s, got %s", clock.Epoch, c.Now())
+		}
+	})
+
+	sweet.Run(t, "only moves when advanced", clock.New, func(t *testing.T, c *clock.Clock) {
+		start := c.Now()
+		c.Advance(time.Minute)
+
+		if c.Since(start) != time.Minute {
+			t.Errorf("expected the clock to move a minute, it moved %s", c.Since(start))
+		}
+	})
+
+	sweet.Run(t, "is shared by composed factories", func(t *testing.T) composedDeps {
+		return composedDeps{
+			clock:     clock.New(t),
+			scheduler: scheduler{clock.New(t)},
+		}
+	}, func(t *testing.T, d composedDeps) {
+		if d.clock != d.scheduler.clock {
+			t.Error("the factories got different clocks")
+		}
+	})
+
+	sweet.Run(t, "is shared by factories composed in parallel", func(t *testing.T) composedDeps {
+		d := composedDeps{}
+		sweet.Parallel(t, sweet.Assign(&d.clock, clock.New), sweet.Assign(&d.scheduler.clock, clock.New))
+		return d
+	}, func(t *testing.T, d composedDeps) {
+		if d.clock != d.scheduler.clock {
+			t.Error("the factories got different clocks")
+		}
+	})
+
+	start := time.Date(2020, time.February, 29, 12, 0, 0, 0, time.UTC)
+	sweet.Run(t, "starts where the factory says", clock.NewFactory(start), func(t *testing.T, c *clock.Clock) {
+		if !c.Now().Equal(start) {
+			t.Errorf("expected %s, got %s", start, c.Now())
+		}
+	})
+}
+
+func TestTimers(t *testing.T) {
+	sweet.Run(t, "fire when due", clock.New, func(t *testing.T, c *clock.Clock) {
+		timer := c.NewTimer(time.Second)
+
+		c.Advance(999 * time.Millisecond)
+		select {
+		case <-timer.C:
+			t.Fatal("the timer fired early")
+		default:
+		}
+
+		c.Advance(time.Millisecond)
+		select {
+		case fired := <-timer.C:
+			if !fired.Equal(clock.Epoch.Add(time.Second)) {
+				t.Errorf("the timer fired at %s", fired)
+			}
+		default:
+			t.Fatal("the timer did not fire")
+		}
+	})
+
+	sweet.Run(t, "do not fire once stopped", clock.New, func(t *testing.T, c *clock.Clock) {
+		timer := c.NewTimer(time.Second)
+
+		if !timer.Stop() {
+			t.Error("stopping an active timer reported it inactive")
+		}
+
+		c.Advance(time.Hour)
+		select {
+		case <-timer.C:
+			t.Error("a stopped timer fired")
+		default:
+		}
+	})
+
+	sweet.Run(t, "run AfterFuncs in order before Advance returns", clock.New, func(t *testing.T, c *clock.Clock) {
+		calls := []time.Time{}
+		c.AfterFunc(2*time.Second, func() { calls = append(calls, c.Now()) })
+		c.AfterFunc(time.Second, func() { calls = append(calls, c.Now()) })
+
+		c.Advance(time.Minute)
+
+		if len(calls) != 2 {
+			t.Fatalf("expected 2 calls, got %d", len(calls))
+		}
+
+		if !calls[0].Equal(clock.Epoch.Add(time.Second)) || !calls[1].Equal(clock.Epoch.Add(2*time.Second)) {
+			t.Errorf("the functions were called at the wrong times: %v", calls)
+		}
+
+		if !c.Now().Equal(clock.Epoch.Add(time.Minute)) {
+			t.Errorf("the clock did not finish advancing: %s", c.Now())
+		}
+	})
+
+	sweet.Run(t, "tickers tick every period", clock.New, func(t *testing.T, c *clock.Clock) {
+		ticker := c.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for i := 1; i <= 3; i++ {
+			c.Advance(time.Second)
+
+			select {
+			case tick := <-ticker.C:
+				if !tick.Equal(clock.Epoch.Add(time.Duration(i) * time.Second)) {
+					t.Errorf("tick %d came at %s", i, tick)
+				}
+			default:
+				t.Fatalf("tick %d never came", i)
+			}
+		}
+	})
+
+	sweet.Run(t, "pending timers can be allowed", clock.New, func(t *testing.T, c *clock.Clock) {
+		c.AllowPending()
+		c.After(time.Hour)
+	})
+}
+
+func TestNew_conformance(t *testing.T) {
+	sweettest.CheckFactory(t, clock.New, sweettest.Options[*clock.Clock]{})
+}
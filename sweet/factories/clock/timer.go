@@ -0,0 +1,102 @@
+package clock
+
+import "time"
+
+// Timer is the fake counterpart of [time.Timer].
+type Timer struct {
+	// C receives the time the timer fired at. It is nil for timers created
+	// with [Clock.AfterFunc].
+	C <-chan time.Time
+
+	c *Clock
+	t *timer
+}
+
+// Ticker is the fake counterpart of [time.Ticker].
+type Ticker struct {
+	// C receives the time of each tick.
+	C <-chan time.Time
+
+	c *Clock
+	t *timer
+}
+
+// NewTimer creates a timer that fires once the clock has advanced by d.
+func (c *Clock) NewTimer(d time.Duration) *Timer {
+	tm := c.add(d, 0, nil)
+	return &Timer{C: tm.c, c: c, t: tm}
+}
+
+// After waits for the clock to advance by d and then sends the current time on
+// the returned channel.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	return c.add(d, 0, nil).c
+}
+
+// AfterFunc calls f once the clock has advanced by d.
+//
+// Unlike [time.AfterFunc] f is called on the goroutine calling
+// [Clock.Advance].
+func (c *Clock) AfterFunc(d time.Duration, f func()) *Timer {
+	return &Timer{c: c, t: c.add(d, 0, f)}
+}
+
+// NewTicker creates a ticker that ticks every time the clock advances by d.
+//
+// It panics if d is not positive.
+func (c *Clock) NewTicker(d time.Duration) *Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	tm := c.add(d, d, nil)
+	return &Ticker{C: tm.c, c: c, t: tm}
+}
+
+// Stop prevents the timer from firing. It returns false if the timer had
+// already fired or been stopped.
+func (t *Timer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	active := t.t.active
+	t.t.active = false
+
+	return active
+}
+
+// Reset changes the timer to fire once the clock has advanced by d. It returns
+// true if the timer had been active.
+func (t *Timer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	active := t.t.active
+	t.t.active = true
+	t.t.when = t.c.now.Add(d)
+
+	return active
+}
+
+// Stop turns off the ticker. No more ticks will be sent.
+func (t *Ticker) Stop() {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	t.t.active = false
+}
+
+// Reset stops the ticker and resets its period to d. The next tick arrives
+// once the clock has advanced by d.
+func (t *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	t.t.active = true
+	t.t.period = d
+	t.t.when = t.c.now.Add(d)
+}
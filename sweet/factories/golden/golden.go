@@ -0,0 +1,239 @@
+// Package golden provides golden file assertions, keyed by the name of the
+// test making them.
+//
+// Run the tests with -sweet.update, or with SWEET_UPDATE=1 set, to write what
+// the tests got as the new golden files. A -update flag the test package
+// defines itself works too.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/internal/diff"
+	"github.com/barry-hennessy/test/sweet/internal/modeflag"
+	"github.com/barry-hennessy/test/sweet/internal/testpath"
+)
+
+// UpdateEnv is the environment variable that turns on update mode, for when
+// the -sweet.update flag can't be used, e.g. with `go test ./...`.
+const UpdateEnv = "SWEET_UPDATE"
+
+var update = modeflag.New("update", UpdateEnv, "write golden files instead of comparing against them")
+
+// Scrubber normalises volatile parts of a value, like timestamps or IDs,
+// before it is compared to or written as a golden file.
+type Scrubber func(data []byte) []byte
+
+// Golden makes golden file assertions for a single test.
+type Golden struct {
+	t         testing.TB
+	dir       string
+	scrubbers []Scrubber
+	calls     int
+}
+
+// Option configures the [Golden] values made by [NewFactory].
+type Option func(*Golden)
+
+// Dir sets the directory golden files are kept in. It defaults to
+// testdata/golden.
+func Dir(dir string) Option {
+	return func(g *Golden) {
+		g.dir = dir
+	}
+}
+
+// Scrub registers scrubbers to run, in order, on every value asserted.
+func Scrub(scrubbers ...Scrubber) Option {
+	return func(g *Golden) {
+		g.scrubbers = append(g.scrubbers, scrubbers...)
+	}
+}
+
+// New is a [sweet.DepFactory] for golden files kept in testdata/golden.
+func New(t *testing.T) *Golden {
+	return NewFactory()(t)
+}
+
+// NewFactory creates a [sweet.DepFactory] for golden files configured by
+// opts.
+func NewFactory(opts ...Option) sweet.DepFactory[*Golden] {
+	return func(t *testing.T) *Golden {
+		g := &Golden{
+			t:   t,
+			dir: filepath.Join("testdata", "golden"),
+		}
+
+		for _, opt := range opts {
+			opt(g)
+		}
+
+		return g
+	}
+}
+
+// ScrubRegexp replaces every match of re with repl, which can refer to
+// submatches as in [regexp.Regexp.ReplaceAll].
+func ScrubRegexp(re *regexp.Regexp, repl string) Scrubber {
+	return func(data []byte) []byte {
+		return re.ReplaceAll(data, []byte(repl))
+	}
+}
+
+// ScrubJSONFields replaces the value of every field named in keys, at any
+// depth, with "<scrubbed>". Data that isn't JSON is left alone.
+func ScrubJSONFields(keys ...string) Scrubber {
+	scrub := map[string]bool{}
+	for _, k := range keys {
+		scrub[k] = true
+	}
+
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, field := range v {
+				if scrub[k] {
+					v[k] = "<scrubbed>"
+				} else {
+					v[k] = walk(field)
+				}
+			}
+		case []any:
+			for i := range v {
+				v[i] = walk(v[i])
+			}
+		}
+
+		return v
+	}
+
+	return func(data []byte) []byte {
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return data
+		}
+
+		scrubbed, err := marshal(walk(v))
+		if err != nil {
+			return data
+		}
+
+		return scrubbed
+	}
+}
+
+// Path returns the golden file the next assertion compares against.
+//
+// The first assertion in a test uses a file named after the test's full
+// path, e.g. testdata/golden/TestServer/GET/ok.golden. Any further assertions
+// in the same test are numbered: ok.2.golden, ok.3.golden and so on.
+func (g *Golden) Path() string {
+	return g.path(g.calls + 1)
+}
+
+func (g *Golden) path(call int) string {
+	name := testpath.FromName(g.t.Name())
+	if call > 1 {
+		name = fmt.Sprintf("%s.%d", name, call)
+	}
+
+	return filepath.Join(g.dir, name+".golden")
+}
+
+// Assert fails the test, with a unified diff, if got does not match the
+// golden file. In update mode it writes got as the golden file instead.
+func (g *Golden) Assert(got []byte) {
+	g.t.Helper()
+
+	g.calls++
+	path := g.path(g.calls)
+
+	for _, scrub := range g.scrubbers {
+		got = scrub(got)
+	}
+
+	if Updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			g.t.Fatalf("golden: creating %s: %s", filepath.Dir(path), err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			g.t.Fatalf("golden: writing %s: %s", path, err)
+		}
+
+		g.t.Logf("golden: updated %s", path)
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		g.t.Errorf("golden: %s does not exist; run the test with -sweet.update to create it", path)
+		return
+	}
+	if err != nil {
+		g.t.Fatalf("golden: reading %s: %s", path, err)
+	}
+
+	if d := diff.Unified(path, "got", want, got); d != "" {
+		g.t.Errorf("golden: the result does not match %s; run the test with -sweet.update to accept it\n%s", path, d)
+	}
+}
+
+// AssertJSON is like [Golden.Assert] for got encoded as indented JSON.
+// Values that are already JSON, as []byte or [json.RawMessage], are
+// re-indented.
+func (g *Golden) AssertJSON(got any) {
+	g.t.Helper()
+
+	var data []byte
+	var err error
+	switch v := got.(type) {
+	case []byte:
+		data, err = indent(v)
+	case json.RawMessage:
+		data, err = indent(v)
+	default:
+		data, err = marshal(got)
+	}
+
+	if err != nil {
+		g.t.Fatalf("golden: encoding JSON: %s", err)
+	}
+
+	g.Assert(data)
+}
+
+// marshal encodes v as indented JSON, leaving HTML characters unescaped so
+// golden files stay readable.
+func marshal(v any) ([]byte, error) {
+	b := &bytes.Buffer{}
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(v)
+
+	return b.Bytes(), err
+}
+
+func indent(data []byte) ([]byte, error) {
+	b := &bytes.Buffer{}
+	err := json.Indent(b, data, "", "  ")
+	b.WriteByte('\n')
+
+	return b.Bytes(), err
+}
+
+// Updating reports whether golden files should be written rather than
+// compared against, i.e. whether -sweet.update, -update or [UpdateEnv] is
+// set.
+func Updating() bool {
+	return update.On()
+}
@@ -0,0 +1,36 @@
+package golden
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/internal/recorder"
+)
+
+func TestAssert_mismatch(t *testing.T) {
+	r := &recorder.TB{TestName: "TestGolden/matches_the_file_named_after_the_test"}
+	g := &Golden{t: r, dir: "testdata/golden"}
+
+	g.Assert([]byte("hello silver\n"))
+
+	if len(r.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %q", r.Errors)
+	}
+
+	for _, expected := range []string{"-hello golden", "+hello silver"} {
+		if !strings.Contains(r.Errors[0], expected) {
+			t.Errorf("the diff does not contain %q:\n%s", expected, r.Errors[0])
+		}
+	}
+}
+
+func TestAssert_missing(t *testing.T) {
+	r := &recorder.TB{TestName: "TestDoesNotExist"}
+	g := &Golden{t: r, dir: "testdata/golden"}
+
+	g.Assert([]byte("anything"))
+
+	if len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "run the test with -sweet.update") {
+		t.Errorf("expected a hint to update, got %q", r.Errors)
+	}
+}
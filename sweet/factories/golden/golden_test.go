@@ -0,0 +1,92 @@
+package golden_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/golden"
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+// update is the package's own -update flag, as golden tests often define,
+// which golden mustn't clash with.
+var update = flag.Bool("update", false, "update the golden files")
+
+type order struct {
+	ID    string   `json:"id"`
+	Items []string `json:"items"`
+}
+
+func TestGolden(t *testing.T) {
+	sweet.Run(t, "matches the file named after the test", golden.New, func(t *testing.T, g *golden.Golden) {
+		g.Assert([]byte("hello golden\n"))
+	})
+
+	sweet.Run(t, "numbers further assertions", golden.New, func(t *testing.T, g *golden.Golden) {
+		g.Assert([]byte("first\n"))
+
+		if filepath.Base(g.Path()) != "numbers_further_assertions.2.golden" {
+			t.Errorf("unexpected path for the second assertion: %s", g.Path())
+		}
+
+		g.Assert([]byte("second\n"))
+	})
+
+	scrubbed := golden.NewFactory(golden.Scrub(
+		golden.ScrubJSONFields("id"),
+		golden.ScrubRegexp(regexp.MustCompile(`\d{4}-\d{2}-\d{2}`), "<date>"),
+	))
+	sweet.Run(t, "scrubs volatile fields", scrubbed, func(t *testing.T, g *golden.Golden) {
+		g.AssertJSON(order{
+			ID:    "e4c9a1f0",
+			Items: []string{"delivered on 2023-02-11"},
+		})
+	})
+}
+
+func TestGolden_update(t *testing.T) {
+	t.Setenv(golden.UpdateEnv, "1")
+
+	dir := t.TempDir()
+	sweet.Run(t, "writes the golden file", golden.NewFactory(golden.Dir(dir)), func(t *testing.T, g *golden.Golden) {
+		path := g.Path()
+		g.Assert([]byte("new\n"))
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("the golden file was not written: %s", err)
+		}
+
+		if string(data) != "new\n" {
+			t.Errorf("unexpected contents %q", data)
+		}
+	})
+}
+
+func TestUpdating(t *testing.T) {
+	if golden.Updating() {
+		t.Skip("the golden files are being updated")
+	}
+
+	if err := flag.Set("sweet.update", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if !golden.Updating() {
+		t.Error("expected -sweet.update to turn update mode on")
+	}
+	flag.Set("sweet.update", "false")
+
+	flag.Set("update", "true")
+	defer flag.Set("update", "false")
+	if !golden.Updating() || !*update {
+		t.Error("expected the package's own -update to turn update mode on")
+	}
+}
+
+func TestNew_conformance(t *testing.T) {
+	sweettest.CheckFactory(t, golden.New, sweettest.Options[*golden.Golden]{})
+}
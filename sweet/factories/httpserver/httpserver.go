@@ -0,0 +1,251 @@
+// Package httpserver provides [httptest.Server] factories that stub routes,
+// record the requests they receive and check both at the end of the test.
+package httpserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// Server is an [httptest.Server] that answers with the stubs registered on it.
+//
+// Every request it receives is recorded. When the test ends the server is
+// closed and the test fails if a stub was not called the expected number of
+// times or a request matched no stub.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	stubs    []*Stub
+	requests []Request
+}
+
+// Request is a request received by a [Server].
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Proto  string
+	Header http.Header
+	Body   []byte
+
+	// Matched reports whether the request was answered by a stub.
+	Matched bool
+}
+
+// Stub answers the requests to a route.
+type Stub struct {
+	method  string
+	path    string
+	handler http.Handler
+
+	mu    sync.Mutex
+	calls int
+	times int
+}
+
+// New is a [sweet.DepFactory] for an HTTP/1.1 [Server].
+func New(t *testing.T) *Server {
+	s := newServer(t)
+	s.Start()
+
+	return s
+}
+
+// NewTLS is a [sweet.DepFactory] for an HTTP/1.1 [Server] using TLS.
+//
+// Use [httptest.Server.Client] to get a client that trusts it.
+func NewTLS(t *testing.T) *Server {
+	s := newServer(t)
+	s.StartTLS()
+
+	return s
+}
+
+// NewHTTP2 is a [sweet.DepFactory] for an HTTP/2 [Server]. HTTP/2 requires
+// TLS.
+//
+// Use [httptest.Server.Client] to get a client that speaks HTTP/2 to it.
+func NewHTTP2(t *testing.T) *Server {
+	s := newServer(t)
+	s.EnableHTTP2 = true
+	s.StartTLS()
+
+	return s
+}
+
+func newServer(t *testing.T) *Server {
+	s := &Server{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+
+	t.Cleanup(func() {
+		s.Close()
+		s.verify(t)
+	})
+
+	sweet.OnFailure(t, s.logRequests)
+
+	return s
+}
+
+// logRequests logs every request the server received.
+func (s *Server) logRequests(t *testing.T) {
+	requests := s.Requests()
+
+	lines := make([]string, 0, len(requests))
+	for _, r := range requests {
+		line := r.Method + " " + r.Path
+		if r.Query != "" {
+			line += "?" + r.Query
+		}
+		if !r.Matched {
+			line += " (unexpected)"
+		}
+		lines = append(lines, line)
+	}
+
+	t.Logf("httpserver: %s received %d request(s):\n\t%s", s.URL, len(requests), strings.Join(lines, "\n\t"))
+}
+
+// Stub registers handler to answer requests for method and path.
+//
+// An empty method matches any method. Stubs are matched in the order they are
+// registered; the first match answers the request.
+func (s *Server) Stub(method, path string, handler http.Handler) *Stub {
+	st := &Stub{
+		method:  method,
+		path:    path,
+		handler: handler,
+		times:   -1,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stubs = append(s.stubs, st)
+
+	return st
+}
+
+// Requests returns every request received so far, in the order they arrived.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// Times sets the number of times the stub must be called before the test
+// ends.
+func (st *Stub) Times(n int) *Stub {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.times = n
+
+	return st
+}
+
+// Calls returns the number of requests the stub has answered.
+func (st *Stub) Calls() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return st.calls
+}
+
+func (st *Stub) String() string {
+	method := st.method
+	if method == "" {
+		method = "*"
+	}
+
+	return method + " " + st.path
+}
+
+func (st *Stub) matches(r *http.Request) bool {
+	return (st.method == "" || st.method == r.Method) && st.path == r.URL.Path
+}
+
+// Respond creates a handler that answers with status and body.
+func Respond(status int, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	})
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("httpserver: reading the request body: %s", err), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	rec := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Proto:  r.Proto,
+		Header: r.Header.Clone(),
+		Body:   body,
+	}
+
+	s.mu.Lock()
+	var stub *Stub
+	for _, st := range s.stubs {
+		if st.matches(r) {
+			stub = st
+			break
+		}
+	}
+	rec.Matched = stub != nil
+	s.requests = append(s.requests, rec)
+	s.mu.Unlock()
+
+	if stub == nil {
+		http.Error(w, fmt.Sprintf("httpserver: no stub for %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+		return
+	}
+
+	stub.mu.Lock()
+	stub.calls++
+	stub.mu.Unlock()
+
+	stub.handler.ServeHTTP(w, r)
+}
+
+// verify fails t for every stub called the wrong number of times and every
+// request no stub answered.
+func (s *Server) verify(t testing.TB) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, st := range s.stubs {
+		st.mu.Lock()
+		if st.times >= 0 && st.calls != st.times {
+			t.Errorf("httpserver: %s was called %d times, expected %d", st, st.calls, st.times)
+		}
+		st.mu.Unlock()
+	}
+
+	unexpected := []string{}
+	for _, r := range s.requests {
+		if !r.Matched {
+			unexpected = append(unexpected, r.Method+" "+r.Path)
+		}
+	}
+
+	if len(unexpected) > 0 {
+		t.Errorf("httpserver: %d unexpected request(s):\n\t%s", len(unexpected), strings.Join(unexpected, "\n\t"))
+	}
+}
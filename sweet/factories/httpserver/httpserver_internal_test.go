@@ -0,0 +1,44 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/internal/recorder"
+)
+
+func TestVerify(t *testing.T) {
+	// Built by hand so the real test isn't failed by the cleanup New registers.
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	defer s.Close()
+
+	s.Stub(http.MethodGet, "/called", Respond(http.StatusOK, "")).Times(1)
+	s.Stub(http.MethodGet, "/never", Respond(http.StatusOK, "")).Times(1)
+	s.Stub(http.MethodGet, "/whenever", Respond(http.StatusOK, ""))
+
+	for _, path := range []string{"/called", "/unknown"} {
+		resp, err := s.Client().Get(s.URL + path)
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	r := &recorder.TB{}
+	s.verify(r)
+
+	if len(r.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %q", r.Errors)
+	}
+
+	if !strings.Contains(r.Errors[0], "GET /never was called 0 times, expected 1") {
+		t.Errorf("the unmet expectation was not reported: %q", r.Errors[0])
+	}
+
+	if !strings.Contains(r.Errors[1], "GET /unknown") {
+		t.Errorf("the unexpected request was not reported: %q", r.Errors[1])
+	}
+}
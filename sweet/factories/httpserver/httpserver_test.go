@@ -0,0 +1,94 @@
+package httpserver_test
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/httpserver"
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+func TestServer(t *testing.T) {
+	factories := map[string]sweet.DepFactory[*httpserver.Server]{
+		"HTTP/1.1":     httpserver.New,
+		"HTTP/1.1 TLS": httpserver.NewTLS,
+		"HTTP/2":       httpserver.NewHTTP2,
+	}
+
+	protos := map[string]string{
+		"HTTP/1.1":     "HTTP/1.1",
+		"HTTP/1.1 TLS": "HTTP/1.1",
+		"HTTP/2":       "HTTP/2.0",
+	}
+
+	for name, factory := range factories {
+		name := name
+		sweet.Run(t, name, factory, func(t *testing.T, s *httpserver.Server) {
+			stub := s.Stub(http.MethodPost, "/greet", httpserver.Respond(http.StatusCreated, "hello")).Times(1)
+
+			resp, err := s.Client().Post(s.URL+"/greet?lang=en", "text/plain", strings.NewReader("hi"))
+			if err != nil {
+				t.Fatalf("request failed: %s", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusCreated || string(body) != "hello" {
+				t.Errorf("unexpected response: %d %q", resp.StatusCode, body)
+			}
+
+			if stub.Calls() != 1 {
+				t.Errorf("expected 1 call, got %d", stub.Calls())
+			}
+
+			requests := s.Requests()
+			if len(requests) != 1 {
+				t.Fatalf("expected 1 recorded request, got %d", len(requests))
+			}
+
+			r := requests[0]
+			if r.Method != http.MethodPost || r.Path != "/greet" || r.Query != "lang=en" || string(r.Body) != "hi" {
+				t.Errorf("the request was recorded wrong: %+v", r)
+			}
+
+			if r.Proto != protos[name] {
+				t.Errorf("expected %s, got %s", protos[name], r.Proto)
+			}
+		})
+	}
+
+	sweet.Run(t, "stubs see the request body", httpserver.New, func(t *testing.T, s *httpserver.Server) {
+		s.Stub("", "/echo", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(w, r.Body)
+		}))
+
+		resp, err := s.Client().Post(s.URL+"/echo", "text/plain", strings.NewReader("echo"))
+		if err != nil {
+			t.Fatalf("request failed: %s", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "echo" {
+			t.Errorf("expected the body to be echoed, got %q", body)
+		}
+	})
+}
+
+func TestNew_conformance(t *testing.T) {
+	sweettest.CheckFactory(t, httpserver.New, sweettest.Options[*httpserver.Server]{
+		Released: func(s *httpserver.Server) bool {
+			conn, err := net.Dial("tcp", s.Listener.Addr().String())
+			if err != nil {
+				return true
+			}
+
+			conn.Close()
+			return false
+		},
+	})
+}
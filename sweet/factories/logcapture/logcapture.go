@@ -0,0 +1,343 @@
+//go:build go1.21
+
+// Package logcapture provides [slog.Logger] factories that log to the test
+// and keep every record logged, so tests can check what the code under test
+// logged.
+//
+// It needs Go 1.21, for log/slog.
+package logcapture
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// Capture is a [slog.Logger] whose records are logged to the test, prefixed
+// with the test's name, and kept for the test to check.
+//
+// When the test ends it fails if the logger was made with [FailOnError] and
+// logged an error that was not allowed with [Capture.AllowError].
+type Capture struct {
+	*slog.Logger
+
+	t           *testing.T
+	level       slog.Leveler
+	failOnError bool
+	redirect    bool
+
+	mu      sync.Mutex
+	records []Record
+	allowed []string
+	done    bool
+}
+
+// Record is a record logged to a [Capture].
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+
+	// Attrs holds the record's attributes, along with those of the logger it
+	// was logged with, keyed by their group qualified key, e.g. "request.id".
+	Attrs map[string]slog.Value
+}
+
+// Option configures the [Capture] values made by [NewFactory].
+type Option func(*Capture)
+
+// Level sets the minimum level of the records captured. It defaults to
+// [slog.LevelDebug].
+func Level(level slog.Leveler) Option {
+	return func(c *Capture) {
+		c.level = level
+	}
+}
+
+// FailOnError fails the test if an error is logged, unless it was allowed
+// with [Capture.AllowError].
+func FailOnError() Option {
+	return func(c *Capture) {
+		c.failOnError = true
+	}
+}
+
+// RedirectDefault makes the logger the default for the duration of the test:
+// the output of [slog.Default] and the standard log package is captured too.
+//
+// The defaults are global, so tests redirecting them run one at a time, even
+// when they're parallel. Tests nested in a test redirecting them can redirect
+// them in turn, as can the test itself: the last capture redirecting them
+// gets the output.
+func RedirectDefault() Option {
+	return func(c *Capture) {
+		c.redirect = true
+	}
+}
+
+// New is a [sweet.DepFactory] for a [Capture] of every level.
+func New(t *testing.T) *Capture {
+	return NewFactory()(t)
+}
+
+// NewFactory creates a [sweet.DepFactory] for a [Capture] configured by opts.
+func NewFactory(opts ...Option) sweet.DepFactory[*Capture] {
+	return func(t *testing.T) *Capture {
+		c := &Capture{
+			t:     t,
+			level: slog.LevelDebug,
+		}
+
+		for _, opt := range opts {
+			opt(c)
+		}
+
+		c.Logger = slog.New(&handler{
+			c: c,
+			text: slog.NewTextHandler(testWriter{c}, &slog.HandlerOptions{
+				Level:       c.level,
+				ReplaceAttr: dropTime,
+			}),
+		})
+
+		t.Cleanup(func() {
+			c.mu.Lock()
+			c.done = true
+			c.mu.Unlock()
+
+			c.verify(t)
+		})
+
+		if c.redirect {
+			redirectDefault(t, c.Logger)
+		}
+
+		return c
+	}
+}
+
+// AllowError allows errors whose message contains substr to be logged to a
+// [Capture] made with [FailOnError].
+func (c *Capture) AllowError(substr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.allowed = append(c.allowed, substr)
+}
+
+// Records returns the records logged so far.
+func (c *Capture) Records() []Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Record(nil), c.records...)
+}
+
+// Logged reports whether a record at level, or above, with the attribute key
+// was logged.
+func (c *Capture) Logged(level slog.Level, key string) bool {
+	for _, r := range c.Records() {
+		if _, ok := r.Attrs[key]; ok && r.Level >= level {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Capture) add(r Record) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.records = append(c.records, r)
+}
+
+// verify fails t for every error logged that was not allowed.
+func (c *Capture) verify(t testing.TB) {
+	t.Helper()
+
+	if !c.failOnError {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	unexpected := []string{}
+	for _, r := range c.records {
+		if r.Level >= slog.LevelError && !c.allowedError(r.Message) {
+			unexpected = append(unexpected, r.Message)
+		}
+	}
+
+	if len(unexpected) > 0 {
+		t.Errorf("logcapture: %d unexpected error(s) logged:\n\t%s", len(unexpected), strings.Join(unexpected, "\n\t"))
+	}
+}
+
+func (c *Capture) allowedError(msg string) bool {
+	for _, substr := range c.allowed {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// testWriter logs each record the text handler formats to the test.
+type testWriter struct {
+	c *Capture
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	// Logging to a test that has ended panics, so the test can't be marked
+	// done between the check and the log. Records logged by goroutines
+	// outliving the test are still kept.
+	w.c.mu.Lock()
+	defer w.c.mu.Unlock()
+
+	if !w.c.done {
+		w.c.t.Logf("[%s] %s", w.c.t.Name(), bytes.TrimSuffix(p, []byte("\n")))
+	}
+	return len(p), nil
+}
+
+func dropTime(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+// handler keeps the records logged to a Capture, and has text formatted
+// versions of them logged to the test.
+type handler struct {
+	c    *Capture
+	text slog.Handler
+
+	// attrs are the attributes added with WithAttrs, group qualified.
+	attrs map[string]slog.Value
+	// prefix qualifies the keys of attributes, from the groups added with
+	// WithGroup.
+	prefix string
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.c.level.Level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make(map[string]slog.Value, len(h.attrs)+r.NumAttrs())
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		flatten(attrs, h.prefix, a)
+		return true
+	})
+
+	h.c.add(Record{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   attrs,
+	})
+
+	return h.text.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(as []slog.Attr) slog.Handler {
+	attrs := make(map[string]slog.Value, len(h.attrs)+len(as))
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+	for _, a := range as {
+		flatten(attrs, h.prefix, a)
+	}
+
+	return &handler{c: h.c, text: h.text.WithAttrs(as), attrs: attrs, prefix: h.prefix}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &handler{c: h.c, text: h.text.WithGroup(name), attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// flatten adds a to attrs, with the attributes of groups keyed by their
+// qualified key.
+func flatten(attrs map[string]slog.Value, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		if a.Key != "" {
+			attrs[prefix+a.Key] = v
+		}
+		return
+	}
+
+	// Attributes of a group without a key are inlined.
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range v.Group() {
+		flatten(attrs, prefix, ga)
+	}
+}
+
+// The tests redirecting the defaults, outermost first.
+var (
+	defaultsMu   sync.Mutex
+	defaultsFree = sync.NewCond(&defaultsMu)
+	// defaultsOwners holds the tests redirecting the defaults, outermost
+	// first, once for each time they did.
+	defaultsOwners []string
+)
+
+// redirectDefault makes l the default logger until t ends, waiting for any
+// other test redirecting the defaults, bar t itself and the test t is nested
+// in, to end.
+func redirectDefault(t *testing.T, l *slog.Logger) {
+	defaultsMu.Lock()
+	for !canRedirect(t.Name()) {
+		defaultsFree.Wait()
+	}
+	defaultsOwners = append(defaultsOwners, t.Name())
+	defaultsMu.Unlock()
+
+	prev := slog.Default()
+	writer, flags, prefix := log.Writer(), log.Flags(), log.Prefix()
+
+	// slog.SetDefault sends the output of the log package to l too, but
+	// doesn't undo that when the previous default is restored.
+	slog.SetDefault(l)
+
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+		log.SetOutput(writer)
+		log.SetFlags(flags)
+		log.SetPrefix(prefix)
+
+		defaultsMu.Lock()
+		defaultsOwners = defaultsOwners[:len(defaultsOwners)-1]
+		defaultsMu.Unlock()
+		defaultsFree.Broadcast()
+	})
+}
+
+// canRedirect reports whether the test name can redirect the defaults: no
+// test has, or the last one to is name or a test it is nested in.
+func canRedirect(name string) bool {
+	if len(defaultsOwners) == 0 {
+		return true
+	}
+
+	last := defaultsOwners[len(defaultsOwners)-1]
+	return last == name || strings.HasPrefix(name, last+"/")
+}
@@ -0,0 +1,220 @@
+//go:build go1.21
+
+package logcapture_test
+
+import (
+	"log"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/logcapture"
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+// failingEnv marks the child process run by TestFailOnError.
+const failingEnv = "LOGCAPTURE_TEST_FAILING"
+
+func TestCapture(t *testing.T) {
+	sweet.Run(t, "keeps records", logcapture.New, func(t *testing.T, logs *logcapture.Capture) {
+		logs.With("request", "r1").WithGroup("db").Error("query failed", "table", "users", slog.Group("conn", "id", 7))
+
+		records := logs.Records()
+		if len(records) != 1 {
+			t.Fatalf("expected 1 record, got %d", len(records))
+		}
+
+		r := records[0]
+		if r.Level != slog.LevelError || r.Message != "query failed" {
+			t.Errorf("got %s %q, want ERROR %q", r.Level, r.Message, "query failed")
+		}
+		want := map[string]string{
+			"request":    "r1",
+			"db.table":   "users",
+			"db.conn.id": "7",
+		}
+		for k, v := range want {
+			if got, ok := r.Attrs[k]; !ok || got.String() != v {
+				t.Errorf("got %s=%v, want %s", k, got, v)
+			}
+		}
+	})
+
+	sweet.Run(t, "logged", logcapture.New, func(t *testing.T, logs *logcapture.Capture) {
+		logs.Info("connected", "addr", "localhost")
+		logs.Error("lost connection", "err", "EOF")
+
+		if !logs.Logged(slog.LevelError, "err") {
+			t.Error("expected an error with err to have been logged")
+		}
+		if logs.Logged(slog.LevelError, "addr") {
+			t.Error("expected no error with addr to have been logged")
+		}
+		if !logs.Logged(slog.LevelInfo, "addr") {
+			t.Error("expected info with addr to have been logged")
+		}
+	})
+
+	sweet.Run(t, "level", logcapture.NewFactory(logcapture.Level(slog.LevelWarn)), func(t *testing.T, logs *logcapture.Capture) {
+		logs.Info("ignored")
+		logs.Warn("kept")
+
+		if records := logs.Records(); len(records) != 1 || records[0].Message != "kept" {
+			t.Errorf("expected only the warning, got %v", records)
+		}
+	})
+
+	sweet.Run(t, "allowed errors", logcapture.NewFactory(logcapture.FailOnError()), func(t *testing.T, logs *logcapture.Capture) {
+		logs.AllowError("connection refused")
+		logs.Error("dial: connection refused")
+	})
+
+	t.Run("a goroutine outliving the test", func(t *testing.T) {
+		var (
+			captured *logcapture.Capture
+			stop     = make(chan struct{})
+			stopped  = make(chan struct{})
+		)
+
+		// Logging while the test ends must neither panic nor be lost.
+		sweet.Run(t, "logs", logcapture.New, func(t *testing.T, logs *logcapture.Capture) {
+			captured = logs
+			go func() {
+				defer close(stopped)
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						logs.Info("still running")
+					}
+				}
+			}()
+		})
+
+		n := len(captured.Records())
+		time.Sleep(10 * time.Millisecond)
+		close(stop)
+		<-stopped
+
+		if len(captured.Records()) <= n {
+			t.Error("expected records logged after the test ended to be kept")
+		}
+	})
+}
+
+func TestRedirectDefault(t *testing.T) {
+	prev := slog.Default()
+	writer := log.Writer()
+
+	sweet.Run(t, "redirected", logcapture.NewFactory(logcapture.RedirectDefault()), func(t *testing.T, logs *logcapture.Capture) {
+		slog.Warn("from slog", "k", "v")
+		log.Print("from log")
+
+		records := logs.Records()
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %v", records)
+		}
+		if records[0].Message != "from slog" || records[1].Message != "from log" {
+			t.Errorf("got %q and %q", records[0].Message, records[1].Message)
+		}
+	})
+
+	if slog.Default() != prev {
+		t.Error("expected the default slog logger to be restored")
+	}
+	if log.Writer() != writer {
+		t.Error("expected the log package's output to be restored")
+	}
+}
+
+func TestRedirectDefault_nested(t *testing.T) {
+	redirect := logcapture.NewFactory(logcapture.RedirectDefault())
+
+	sweet.Run(t, "outer", redirect, func(t *testing.T, outer *logcapture.Capture) {
+		sweet.Run(t, "inner", redirect, func(t *testing.T, inner *logcapture.Capture) {
+			log.Print("inner")
+
+			if len(inner.Records()) != 1 {
+				t.Errorf("expected the inner test to capture the log, got %v", inner.Records())
+			}
+		})
+
+		log.Print("outer")
+
+		if records := outer.Records(); len(records) != 1 || records[0].Message != "outer" {
+			t.Errorf("expected the outer test to capture only its log, got %v", records)
+		}
+	})
+}
+
+func TestRedirectDefault_twice(t *testing.T) {
+	type deps struct {
+		first, second *logcapture.Capture
+	}
+	redirect := logcapture.NewFactory(logcapture.RedirectDefault())
+	prev := slog.Default()
+
+	sweet.Run(t, "by one test", func(t *testing.T) deps {
+		return deps{first: redirect(t), second: redirect(t)}
+	}, func(t *testing.T, d deps) {
+		log.Print("second")
+
+		if records := d.second.Records(); len(records) != 1 {
+			t.Errorf("expected the last capture to redirect the log, got %v", records)
+		}
+		if records := d.first.Records(); len(records) != 0 {
+			t.Errorf("expected the first capture to be redirected from, got %v", records)
+		}
+	})
+
+	if slog.Default() != prev {
+		t.Error("expected the default slog logger to be restored")
+	}
+
+	sweet.Run(t, "then by another", redirect, func(t *testing.T, logs *logcapture.Capture) {
+		log.Print("another")
+
+		if len(logs.Records()) != 1 {
+			t.Errorf("expected the defaults to be free to redirect, got %v", logs.Records())
+		}
+	})
+}
+
+func TestFailOnError(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFailOnError_fails$", "-test.v", "-test.count=1")
+	cmd.Env = append(os.Environ(), failingEnv+"=1")
+
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("expected the test to fail, got %v:\n%s", err, out)
+	}
+
+	for _, want := range []string{
+		"[TestFailOnError_fails/unexpected] level=ERROR msg=boom id=1",
+		"logcapture: 1 unexpected error(s) logged:",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestFailOnError_fails(t *testing.T) {
+	if os.Getenv(failingEnv) == "" {
+		t.Skip("only run by TestFailOnError")
+	}
+
+	sweet.Run(t, "unexpected", logcapture.NewFactory(logcapture.FailOnError()), func(t *testing.T, logs *logcapture.Capture) {
+		logs.Error("boom", "id", 1)
+		logs.Warn("not an error")
+	})
+}
+
+func TestNew_conformance(t *testing.T) {
+	sweettest.CheckFactory(t, logcapture.New, sweettest.Options[*logcapture.Capture]{})
+}
@@ -0,0 +1,326 @@
+// Package sandbox provides factories that set environment variables, flags
+// and command line arguments for the duration of a test, and restore them
+// when it ends.
+//
+// Unlike [testing.T.Setenv] it works in parallel tests: the process wide
+// state is shared, so tests setting the same variable, flag or the arguments
+// take turns rather than panicking. Tests setting different ones run in
+// parallel.
+package sandbox
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// Sandbox sets environment variables, flags and arguments until its test
+// ends, when they are restored to what they were.
+//
+// Setting one waits for any other test that set it to end, bar the tests the
+// test is nested in, which it can set it over.
+//
+// As with sweet.Limit, tests only take turns if they call
+// [testing.T.Parallel] before the factory, e.g. in a factory wrapping it,
+// rather than in the test body. A test that sets something and then pauses
+// to run in parallel holds it until its parent's body returns, so a sibling
+// running sequentially that sets it too would wait for ever; it fails after
+// [MaxWait] instead, naming the test it waited for.
+type Sandbox struct {
+	t *testing.T
+
+	changes []change
+}
+
+// change is an environment variable, flag or the arguments to set.
+type change struct {
+	// key names what is set, tests setting the same key take turns.
+	key string
+	// apply sets it and returns the function restoring it.
+	apply func(t *testing.T) (restore func())
+}
+
+// Option configures the [Sandbox] values made by [NewFactory].
+type Option func(*Sandbox)
+
+// Env sets the environment variable key to value.
+func Env(key, value string) Option {
+	return func(s *Sandbox) {
+		s.changes = append(s.changes, setenv(key, value))
+	}
+}
+
+// Unset unsets the environment variable key.
+func Unset(key string) Option {
+	return func(s *Sandbox) {
+		s.changes = append(s.changes, unsetenv(key))
+	}
+}
+
+// Flag sets the flag name, of [flag.CommandLine], to value.
+func Flag(name, value string) Option {
+	return func(s *Sandbox) {
+		s.changes = append(s.changes, setFlag(name, value))
+	}
+}
+
+// Args sets the command line arguments, [os.Args] after the program name, to
+// args.
+func Args(args ...string) Option {
+	return func(s *Sandbox) {
+		s.changes = append(s.changes, setArgs(args))
+	}
+}
+
+// New is a [sweet.DepFactory] for a [Sandbox] that sets nothing until the
+// test sets something with it.
+func New(t *testing.T) *Sandbox {
+	return NewFactory()(t)
+}
+
+// NewFactory creates a [sweet.DepFactory] for a [Sandbox] that sets what
+// opts set.
+//
+// Everything opts set is waited for at once, so tests setting several of the
+// same things can't each hold one that another is waiting for.
+func NewFactory(opts ...Option) sweet.DepFactory[*Sandbox] {
+	return func(t *testing.T) *Sandbox {
+		t.Helper()
+
+		s := &Sandbox{t: t}
+		for _, opt := range opts {
+			opt(s)
+		}
+
+		s.apply(s.changes...)
+		s.changes = nil
+
+		return s
+	}
+}
+
+// Setenv sets the environment variable key to value until the test ends.
+func (s *Sandbox) Setenv(key, value string) {
+	s.t.Helper()
+	s.apply(setenv(key, value))
+}
+
+// Unsetenv unsets the environment variable key until the test ends.
+func (s *Sandbox) Unsetenv(key string) {
+	s.t.Helper()
+	s.apply(unsetenv(key))
+}
+
+// SetFlag sets the flag name, of [flag.CommandLine], to value until the test
+// ends.
+func (s *Sandbox) SetFlag(name, value string) {
+	s.t.Helper()
+	s.apply(setFlag(name, value))
+}
+
+// SetArgs sets the command line arguments, [os.Args] after the program name,
+// to args until the test ends.
+func (s *Sandbox) SetArgs(args ...string) {
+	s.t.Helper()
+	s.apply(setArgs(args))
+}
+
+// apply waits for the keys of changes, then makes them. They are restored,
+// in reverse, before the keys are released.
+func (s *Sandbox) apply(changes ...change) {
+	s.t.Helper()
+
+	keys := make([]string, 0, len(changes))
+	for _, c := range changes {
+		keys = append(keys, c.key)
+	}
+	acquire(s.t, keys)
+
+	for _, c := range changes {
+		if restore := c.apply(s.t); restore != nil {
+			s.t.Cleanup(restore)
+		}
+	}
+}
+
+func setenv(key, value string) change {
+	return change{
+		key: "env:" + key,
+		apply: func(t *testing.T) func() {
+			t.Helper()
+
+			restore := envRestorer(key)
+			if err := os.Setenv(key, value); err != nil {
+				t.Fatalf("sandbox: setting %s: %s", key, err)
+			}
+			return restore
+		},
+	}
+}
+
+func unsetenv(key string) change {
+	return change{
+		key: "env:" + key,
+		apply: func(t *testing.T) func() {
+			t.Helper()
+
+			restore := envRestorer(key)
+			if err := os.Unsetenv(key); err != nil {
+				t.Fatalf("sandbox: unsetting %s: %s", key, err)
+			}
+			return restore
+		},
+	}
+}
+
+// envRestorer returns a function restoring the environment variable key to
+// its current value, or unsetting it if it isn't set.
+func envRestorer(key string) func() {
+	old, ok := os.LookupEnv(key)
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func setFlag(name, value string) change {
+	return change{
+		key: "flag:" + name,
+		apply: func(t *testing.T) func() {
+			t.Helper()
+
+			f := flag.CommandLine.Lookup(name)
+			if f == nil {
+				t.Fatalf("sandbox: no flag -%s is defined", name)
+			}
+
+			// The flag's value is set directly, rather than with
+			// flag.CommandLine.Set, so the flag isn't marked as set for
+			// flag.Visit once it's restored.
+			old := f.Value.String()
+			if err := f.Value.Set(value); err != nil {
+				t.Fatalf("sandbox: setting -%s: %s", name, err)
+			}
+
+			return func() {
+				if err := f.Value.Set(old); err != nil {
+					t.Errorf("sandbox: restoring -%s: %s", name, err)
+				}
+			}
+		},
+	}
+}
+
+func setArgs(args []string) change {
+	return change{
+		key: "args",
+		apply: func(t *testing.T) func() {
+			old := os.Args
+
+			os.Args = append([]string{old[0]}, args...)
+
+			return func() {
+				os.Args = old
+			}
+		},
+	}
+}
+
+// MaxWait is how long setting something waits for the test holding it to end
+// before failing. Set it before running any tests.
+var MaxWait = time.Minute
+
+// The tests holding each key, outermost first.
+var (
+	keysMu   sync.Mutex
+	keysFree = sync.NewCond(&keysMu)
+	holders  = map[string][]string{}
+)
+
+// acquire waits until t can hold all of keys, then holds them until it ends.
+//
+// t fails if it waits longer than [MaxWait], which it would for ever if the
+// test holding a key is paused to run in parallel until t has finished.
+func acquire(t *testing.T, keys []string) {
+	t.Helper()
+
+	name := t.Name()
+	start := time.Now()
+
+	// Wake the wait below once it's waited too long, to fail.
+	timeout := time.AfterFunc(MaxWait, func() {
+		keysMu.Lock()
+		defer keysMu.Unlock()
+		keysFree.Broadcast()
+	})
+	defer timeout.Stop()
+
+	keysMu.Lock()
+	for {
+		key, holder, blocked := blocker(name, keys)
+		if !blocked {
+			break
+		}
+		if waited := time.Since(start); waited >= MaxWait {
+			keysMu.Unlock()
+			t.Fatalf("sandbox: waited %s for %s, held by %s; if it called t.Parallel after setting it, it is paused until %s has finished, so call t.Parallel before the factory rather than in the test body", waited.Round(time.Millisecond), key, holder, name)
+		}
+		keysFree.Wait()
+	}
+	for _, key := range keys {
+		holders[key] = append(holders[key], name)
+	}
+	keysMu.Unlock()
+
+	t.Cleanup(func() {
+		keysMu.Lock()
+		for _, key := range keys {
+			release(key, name)
+		}
+		keysMu.Unlock()
+		keysFree.Broadcast()
+	})
+}
+
+// blocker returns a key of keys the test name can't hold yet, and the test
+// holding it. Keys are free to hold if nothing holds them, the test itself
+// does or a test it is nested in does.
+func blocker(name string, keys []string) (key, holder string, blocked bool) {
+	for _, key := range keys {
+		held := holders[key]
+		if len(held) == 0 {
+			continue
+		}
+
+		last := held[len(held)-1]
+		if last != name && !strings.HasPrefix(name, last+"/") {
+			return key, last, true
+		}
+	}
+	return "", "", false
+}
+
+// release drops the last hold name has on key.
+func release(key, name string) {
+	held := holders[key]
+	for i := len(held) - 1; i >= 0; i-- {
+		if held[i] == name {
+			held = append(held[:i], held[i+1:]...)
+			break
+		}
+	}
+
+	if len(held) == 0 {
+		delete(holders, key)
+		return
+	}
+	holders[key] = held
+}
@@ -0,0 +1,185 @@
+package sandbox_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/sandbox"
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+// failingEnv marks the child process run by TestSandbox_pausedHolder.
+const failingEnv = "SANDBOX_TEST_FAILING"
+
+var level = flag.String("sandbox-test-level", "info", "a flag for the sandbox tests to set")
+
+func TestSandbox(t *testing.T) {
+	os.Setenv("SANDBOX_TEST_SET", "before")
+	defer os.Unsetenv("SANDBOX_TEST_SET")
+	os.Unsetenv("SANDBOX_TEST_UNSET")
+	args := os.Args
+
+	factory := sandbox.NewFactory(
+		sandbox.Env("SANDBOX_TEST_SET", "during"),
+		sandbox.Env("SANDBOX_TEST_UNSET", "during"),
+		sandbox.Flag("sandbox-test-level", "debug"),
+		sandbox.Args("serve", "-v"),
+	)
+
+	sweet.Run(t, "applies", factory, func(t *testing.T, s *sandbox.Sandbox) {
+		if got := os.Getenv("SANDBOX_TEST_SET"); got != "during" {
+			t.Errorf("got SANDBOX_TEST_SET=%q, want %q", got, "during")
+		}
+		if got := os.Getenv("SANDBOX_TEST_UNSET"); got != "during" {
+			t.Errorf("got SANDBOX_TEST_UNSET=%q, want %q", got, "during")
+		}
+		if *level != "debug" {
+			t.Errorf("got -sandbox-test-level=%q, want %q", *level, "debug")
+		}
+		if got := strings.Join(os.Args[1:], " "); got != "serve -v" || os.Args[0] != args[0] {
+			t.Errorf("got args %q, want the program name followed by serve -v", os.Args)
+		}
+
+		s.Unsetenv("SANDBOX_TEST_SET")
+		s.SetFlag("sandbox-test-level", "warn")
+		s.SetArgs("migrate")
+
+		if _, ok := os.LookupEnv("SANDBOX_TEST_SET"); ok {
+			t.Error("expected SANDBOX_TEST_SET to be unset")
+		}
+		if *level != "warn" {
+			t.Errorf("got -sandbox-test-level=%q, want %q", *level, "warn")
+		}
+		if got := strings.Join(os.Args[1:], " "); got != "migrate" {
+			t.Errorf("got args %q, want the program name followed by migrate", os.Args)
+		}
+	})
+
+	if got := os.Getenv("SANDBOX_TEST_SET"); got != "before" {
+		t.Errorf("expected SANDBOX_TEST_SET to be restored, got %q", got)
+	}
+	if _, ok := os.LookupEnv("SANDBOX_TEST_UNSET"); ok {
+		t.Error("expected SANDBOX_TEST_UNSET to be unset again")
+	}
+	if *level != "info" {
+		t.Errorf("expected -sandbox-test-level to be restored, got %q", *level)
+	}
+	if len(os.Args) != len(args) || &os.Args[0] != &args[0] {
+		t.Errorf("expected the args to be restored, got %q", os.Args)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "sandbox-test-level" {
+			t.Error("expected -sandbox-test-level not to be marked as set")
+		}
+	})
+}
+
+func TestSandbox_nested(t *testing.T) {
+	outer := sandbox.NewFactory(sandbox.Env("SANDBOX_TEST_NESTED", "outer"))
+	inner := sandbox.NewFactory(sandbox.Env("SANDBOX_TEST_NESTED", "inner"))
+
+	sweet.Run(t, "outer", outer, func(t *testing.T, s *sandbox.Sandbox) {
+		// Nested tests can set what the tests they're nested in set,
+		// without waiting for them to end.
+		sweet.Run(t, "inner", inner, func(t *testing.T, s *sandbox.Sandbox) {
+			if got := os.Getenv("SANDBOX_TEST_NESTED"); got != "inner" {
+				t.Errorf("got %q, want %q", got, "inner")
+			}
+		})
+
+		if got := os.Getenv("SANDBOX_TEST_NESTED"); got != "outer" {
+			t.Errorf("expected the outer value to be restored, got %q", got)
+		}
+	})
+}
+
+func TestSandbox_parallel(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		inside, most int
+	)
+
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			i := i
+			value := fmt.Sprint(i)
+
+			factory := func(t *testing.T) *sandbox.Sandbox {
+				t.Parallel()
+				return sandbox.NewFactory(sandbox.Env("SANDBOX_TEST_PARALLEL", value))(t)
+			}
+
+			sweet.Run(t, fmt.Sprintf("test %d", i), factory, func(t *testing.T, s *sandbox.Sandbox) {
+				mu.Lock()
+				inside++
+				if inside > most {
+					most = inside
+				}
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+				if got := os.Getenv("SANDBOX_TEST_PARALLEL"); got != value {
+					t.Errorf("got %q, want %q: another test changed it", got, value)
+				}
+
+				mu.Lock()
+				inside--
+				mu.Unlock()
+			})
+		}
+	})
+
+	if most != 1 {
+		t.Errorf("expected the tests setting the same variable to take turns, %d ran at once", most)
+	}
+	if _, ok := os.LookupEnv("SANDBOX_TEST_PARALLEL"); ok {
+		t.Error("expected SANDBOX_TEST_PARALLEL to be unset again")
+	}
+}
+
+func TestSandbox_pausedHolder(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSandbox_pausedHolderFails$", "-test.v", "-test.count=1", "-test.timeout=30s")
+	cmd.Env = append(os.Environ(), failingEnv+"=1")
+
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("expected the test to fail, got %v:\n%s", err, out)
+	}
+
+	for _, want := range []string{
+		"--- PASS: TestSandbox_pausedHolderFails/first",
+		"--- FAIL: TestSandbox_pausedHolderFails/second",
+		"for env:SANDBOX_TEST_PAUSED, held by TestSandbox_pausedHolderFails/first; if it called t.Parallel after setting it, it is paused until TestSandbox_pausedHolderFails/second has finished",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestSandbox_pausedHolderFails(t *testing.T) {
+	if os.Getenv(failingEnv) == "" {
+		t.Skip("only run by TestSandbox_pausedHolder")
+	}
+
+	sandbox.MaxWait = 50 * time.Millisecond
+	factory := sandbox.NewFactory(sandbox.Env("SANDBOX_TEST_PAUSED", "set"))
+
+	// first holds the variable while paused, until second has finished.
+	sweet.Run(t, "first", factory, func(t *testing.T, s *sandbox.Sandbox) {
+		t.Parallel()
+	})
+	sweet.Run(t, "second", factory, func(t *testing.T, s *sandbox.Sandbox) {})
+}
+
+func TestNew_conformance(t *testing.T) {
+	sweettest.CheckFactory(t, sandbox.New, sweettest.Options[*sandbox.Sandbox]{})
+}
@@ -2,8 +2,10 @@ package tc
 
 import (
 	"context"
+	"io"
 	"testing"
 
+	"github.com/barry-hennessy/test/sweet"
 	"github.com/testcontainers/testcontainers-go"
 )
 
@@ -14,7 +16,8 @@ type Container interface {
 
 // NewContainer sets up and runs a docker container for the given image.
 //
-// The container is cleaned up when the test ends.
+// The container is cleaned up when the test ends, even if ctx is done by then,
+// as [sweet.Context] is.
 func NewContainer(t *testing.T, ctx context.Context, c Container) testcontainers.Container {
 	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
 		ContainerRequest: c.Request(),
@@ -28,18 +31,47 @@ func NewContainer(t *testing.T, ctx context.Context, c Container) testcontainers
 	}
 
 	t.Cleanup(func() {
-		if err := c.Close(ctx, container); err != nil {
+		if err := c.Close(context.Background(), container); err != nil {
 			t.Logf("failed to terminate container: %s", err)
 		}
 	})
 
+	sweet.OnFailure(t, func(t *testing.T) {
+		logContainer(t, ctx, container)
+	})
+
 	return container
 }
 
+// logContainer logs the output of a container, to diagnose failing tests.
+func logContainer(t *testing.T, ctx context.Context, container testcontainers.Container) {
+	logs, err := container.Logs(ctx)
+	if err != nil {
+		t.Logf("could not get container logs: %s", err)
+		return
+	}
+	defer logs.Close()
+
+	out, err := io.ReadAll(logs)
+	if err != nil {
+		t.Logf("could not read container logs: %s", err)
+	}
+
+	t.Logf("container %s logs:\n%s", container.GetContainerID(), out)
+}
+
+// ResourceClass is the [sweet.Limit] class of the factories made by
+// [NewFactory]. Limit how many containers start at once with
+// SWEET_LIMITS=docker:4 or [sweet.SetLimit].
+const ResourceClass = "docker"
+
 // NewFactory generates a sweet compatible DepFactory that  spins up Redis test
 // containers of the given image.
+//
+// Containers start in the [ResourceClass] queue, with the test's
+// [sweet.Context], so they stop starting if the test does. ctx is not used.
 func NewFactory(ctx context.Context, c Container) func(t *testing.T) testcontainers.Container {
-	return func(t *testing.T) testcontainers.Container {
-		return NewContainer(t, ctx, c)
-	}
+	return sweet.Limit(ResourceClass, func(t *testing.T) testcontainers.Container {
+		return NewContainer(t, sweet.Context(t), c)
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/barry-hennessy/test/sweet"
 	"github.com/barry-hennessy/test/sweet/factories/tc"
@@ -12,6 +13,7 @@ import (
 	"github.com/barry-hennessy/test/sweet/factories/tc/nats"
 	"github.com/barry-hennessy/test/sweet/factories/tc/postgres"
 	"github.com/barry-hennessy/test/sweet/factories/tc/redis"
+	"github.com/barry-hennessy/test/sweet/sweettest"
 	"github.com/testcontainers/testcontainers-go"
 )
 
@@ -56,4 +58,61 @@ func TestNewFactory(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("keep to the container contract", func(t *testing.T) {
+		factories := map[string]sweet.DepFactory[testcontainers.Container]{
+			"redis:7":     tc.NewFactory(ctx, redis.NewRedisContainer("redis:7")),
+			"postgres:15": tc.NewFactory(ctx, postgres.NewPostgresContainer("postgres:15")),
+		}
+
+		containerContract := sweet.Contract[testcontainers.Container]{
+			"is running": func(t *testing.T, c testcontainers.Container) {
+				state, err := c.State(ctx)
+				if err != nil {
+					t.Errorf("Could not get container state: %q", err)
+					return
+				}
+
+				if !state.Running {
+					t.Errorf("Container is not running")
+				}
+			},
+			"has an endpoint": func(t *testing.T, c testcontainers.Container) {
+				endpoint, err := c.Endpoint(ctx, "")
+				if err != nil {
+					t.Errorf("Could not get container endpoint: %q", err)
+					return
+				}
+
+				if endpoint == "" {
+					t.Errorf("Container has no endpoint")
+				}
+			},
+		}
+
+		containerContract.Run(t, factories)
+	})
+}
+
+func TestNewFactory_conformance(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		// See https://github.com/barry-hennessy/test/issues/11
+		t.Skip()
+		return
+	}
+
+	ctx := context.Background()
+	factory := tc.NewFactory(ctx, redis.NewRedisContainer("redis:7"))
+
+	sweettest.CheckFactory(t, factory, sweettest.Options[testcontainers.Container]{
+		Released: func(c testcontainers.Container) bool {
+			state, err := c.State(ctx)
+			return err != nil || !state.Running
+		},
+		// Containers take a while to stop
+		LeakTimeout: 10 * time.Second,
+		// The docker client keeps its connections, and the reaper, running
+		// between tests
+		IgnoreGoroutines: []string{"net/http.(*persistConn)", "testcontainers-go"},
+	})
 }
@@ -0,0 +1,276 @@
+// Package tempfs provides temporary directories seeded from an [fs.FS], for
+// tests of code that works with files.
+//
+// Seed directories from testdata with [Testdata], from an [embed.FS] with
+// [fs.Sub], or from an in memory [fstest.MapFS].
+package tempfs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// Dir is a temporary directory, removed when the test ends.
+type Dir struct {
+	// Path is the absolute path of the directory.
+	Path string
+}
+
+// Option configures the directories made by [NewFactory].
+type Option func(*config)
+
+type config struct {
+	chdir bool
+	want  fs.FS
+}
+
+// Chdir changes the working directory to the temporary directory for the
+// duration of the test, restoring it afterwards.
+//
+// The working directory is shared by the whole process, so tests using this
+// must not run in parallel.
+func Chdir() Option {
+	return func(c *config) {
+		c.chdir = true
+	}
+}
+
+// Expect fails the test if, when it ends, the directory does not match want
+// exactly. It supports golden directory tests.
+func Expect(want fs.FS) Option {
+	return func(c *config) {
+		c.want = want
+	}
+}
+
+// Testdata returns the file system rooted at the given testdata directory.
+//
+// Unlike [os.DirFS] on a relative path it keeps working after the working
+// directory changes, e.g. with [Chdir].
+func Testdata(dir string) fs.FS {
+	abs, err := filepath.Abs(filepath.Join("testdata", dir))
+	if err != nil {
+		panic(fmt.Sprintf("tempfs: resolving testdata directory %q: %s", dir, err))
+	}
+
+	return os.DirFS(abs)
+}
+
+// NewFactory creates a [sweet.DepFactory] for temporary directories holding a
+// copy of src. A nil src gives an empty directory.
+func NewFactory(src fs.FS, opts ...Option) sweet.DepFactory[*Dir] {
+	c := config{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return func(t *testing.T) *Dir {
+		t.Helper()
+
+		d := &Dir{Path: t.TempDir()}
+
+		if src != nil {
+			if err := copyFS(d.Path, src); err != nil {
+				t.Fatalf("tempfs: seeding %s: %s", d.Path, err)
+			}
+		}
+
+		sweet.OnFailure(t, d.logTree)
+
+		// Registered before changing directory so it runs after the original
+		// working directory is restored, where relative paths in want resolve.
+		if c.want != nil {
+			t.Cleanup(func() {
+				d.check(t, c.want)
+			})
+		}
+
+		if c.chdir {
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Fatalf("tempfs: getting the working directory: %s", err)
+			}
+
+			if err := os.Chdir(d.Path); err != nil {
+				t.Fatalf("tempfs: changing to %s: %s", d.Path, err)
+			}
+
+			t.Cleanup(func() {
+				if err := os.Chdir(wd); err != nil {
+					t.Errorf("tempfs: restoring the working directory %s: %s", wd, err)
+				}
+			})
+		}
+
+		return d
+	}
+}
+
+// Join joins elem onto the directory's path.
+func (d *Dir) Join(elem ...string) string {
+	return filepath.Join(append([]string{d.Path}, elem...)...)
+}
+
+// FS returns the directory as an [fs.FS].
+func (d *Dir) FS() fs.FS {
+	return os.DirFS(d.Path)
+}
+
+// Diff compares the directory with want. It returns one line per file that is
+// missing, unexpected or has different contents, sorted by path.
+func (d *Dir) Diff(want fs.FS) ([]string, error) {
+	got, err := readTree(d.FS())
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := readTree(want)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []string{}
+	for name, w := range expected {
+		g, ok := got[name]
+		switch {
+		case !ok:
+			diffs = append(diffs, "missing: "+name)
+		case g.dir != w.dir:
+			diffs = append(diffs, "type differs: "+name)
+		case !bytes.Equal(g.data, w.data):
+			diffs = append(diffs, "contents differ: "+name)
+		}
+	}
+
+	for name := range got {
+		if _, ok := expected[name]; !ok {
+			diffs = append(diffs, "unexpected: "+name)
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffPath(diffs[i]) < diffPath(diffs[j])
+	})
+
+	return diffs, nil
+}
+
+// logTree logs every file in the directory, with its size.
+func (d *Dir) logTree(t *testing.T) {
+	tree, err := readTree(d.FS())
+	if err != nil {
+		t.Logf("tempfs: reading %s: %s", d.Path, err)
+		return
+	}
+
+	lines := make([]string, 0, len(tree))
+	for name, e := range tree {
+		if e.dir {
+			lines = append(lines, name+"/")
+		} else {
+			lines = append(lines, fmt.Sprintf("%s (%d bytes)", name, len(e.data)))
+		}
+	}
+	sort.Strings(lines)
+
+	t.Logf("tempfs: %s holds:\n\t%s", d.Path, strings.Join(lines, "\n\t"))
+}
+
+// check fails t if the directory does not match want.
+func (d *Dir) check(t testing.TB, want fs.FS) {
+	t.Helper()
+
+	diffs, err := d.Diff(want)
+	if err != nil {
+		t.Errorf("tempfs: comparing %s: %s", d.Path, err)
+		return
+	}
+
+	if len(diffs) > 0 {
+		t.Errorf("tempfs: %s does not match the expected tree:\n\t%s", d.Path, strings.Join(diffs, "\n\t"))
+	}
+}
+
+type entry struct {
+	dir  bool
+	data []byte
+}
+
+// readTree reads every file and directory in fsys, keyed by path.
+func readTree(fsys fs.FS) (map[string]entry, error) {
+	tree := map[string]entry{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, de fs.DirEntry, err error) error {
+		if err != nil || name == "." {
+			return err
+		}
+
+		if de.IsDir() {
+			tree[name] = entry{dir: true}
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		tree[name] = entry{data: data}
+
+		return nil
+	})
+
+	return tree, err
+}
+
+// copyFS copies every file and directory in src into dir.
+func copyFS(dir string, src fs.FS) error {
+	return fs.WalkDir(src, ".", func(name string, de fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if de.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+
+		info, err := de.Info()
+		if err != nil {
+			return err
+		}
+
+		in, err := src.Open(name)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		// Seeds are often read only, e.g. from an embed.FS, but tests need
+		// to be able to change their copy.
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm()|0o600)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+
+		return out.Close()
+	})
+}
+
+func diffPath(diff string) string {
+	return path.Clean(diff[strings.Index(diff, ": ")+2:])
+}
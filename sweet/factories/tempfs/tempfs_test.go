@@ -0,0 +1,119 @@
+package tempfs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/tempfs"
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+func TestNewFactory(t *testing.T) {
+	seeds := map[string]sweet.DepFactory[*tempfs.Dir]{
+		"testdata": tempfs.NewFactory(tempfs.Testdata("seed")),
+		"MapFS": tempfs.NewFactory(fstest.MapFS{
+			"greeting.txt":    {Data: []byte("hello\n")},
+			"nested/file.txt": {Data: []byte("deep\n"), Mode: 0o444},
+		}),
+	}
+
+	for name, factory := range seeds {
+		sweet.Run(t, "seeded from "+name, factory, func(t *testing.T, d *tempfs.Dir) {
+			data, err := os.ReadFile(d.Join("nested", "file.txt"))
+			if err != nil {
+				t.Fatalf("the seed was not copied: %s", err)
+			}
+
+			if string(data) != "deep\n" {
+				t.Errorf("unexpected contents %q", data)
+			}
+
+			if err := os.WriteFile(d.Join("nested", "file.txt"), []byte("changed"), 0o644); err != nil {
+				t.Errorf("the copy is not writable: %s", err)
+			}
+		})
+	}
+
+	sweet.Run(t, "every test gets a fresh copy", tempfs.NewFactory(tempfs.Testdata("seed")), func(t *testing.T, d *tempfs.Dir) {
+		diffs, err := d.Diff(tempfs.Testdata("seed"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(diffs) != 0 {
+			t.Errorf("the copy differs from the seed: %q", diffs)
+		}
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chdirFactory := tempfs.NewFactory(tempfs.Testdata("seed"), tempfs.Chdir(), tempfs.Expect(tempfs.Testdata("want")))
+	sweet.Run(t, "changes into the directory and matches the golden tree", chdirFactory, func(t *testing.T, d *tempfs.Dir) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cwd != d.Path {
+			resolved, _ := filepath.EvalSymlinks(d.Path)
+			if cwd != resolved {
+				t.Errorf("expected to be in %s, in %s", d.Path, cwd)
+			}
+		}
+
+		if err := os.WriteFile("output.txt", []byte("written\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if cwd, _ := os.Getwd(); cwd != wd {
+		t.Errorf("the working directory was not restored: %s", cwd)
+	}
+}
+
+func TestDir_Diff(t *testing.T) {
+	sweet.Run(t, "lists every difference", tempfs.NewFactory(tempfs.Testdata("seed")), func(t *testing.T, d *tempfs.Dir) {
+		if err := os.WriteFile(d.Join("greeting.txt"), []byte("bye\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		diffs, err := d.Diff(fstest.MapFS{
+			"greeting.txt":     {Data: []byte("hello\n")},
+			"nested/file.txt":  {Data: []byte("deep\n")},
+			"nested/other.txt": {Data: []byte("missing\n")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{
+			"contents differ: greeting.txt",
+			"missing: nested/other.txt",
+		}
+
+		if len(diffs) != len(expected) {
+			t.Fatalf("expected %q, got %q", expected, diffs)
+		}
+
+		for i := range expected {
+			if diffs[i] != expected[i] {
+				t.Errorf("expected %q, got %q", expected[i], diffs[i])
+			}
+		}
+	})
+}
+
+func TestNewFactory_conformance(t *testing.T) {
+	sweettest.CheckFactory(t, tempfs.NewFactory(tempfs.Testdata("seed")), sweettest.Options[*tempfs.Dir]{
+		Released: func(d *tempfs.Dir) bool {
+			_, err := os.Stat(d.Path)
+			return os.IsNotExist(err)
+		},
+	})
+}
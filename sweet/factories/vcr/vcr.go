@@ -0,0 +1,443 @@
+// Package vcr provides [http.Client] factories that record the requests a
+// test makes, and the responses it gets, to cassette files, and replay them
+// in later runs without touching the network.
+//
+// Cassettes are kept in testdata/cassettes, named after the test. Tests
+// replay them by default, and fail if theirs is missing. Run the tests with
+// -sweet.record, or with SWEET_RECORD=1 set, to make real requests and record
+// them as new cassettes. A -record flag the test package defines itself works
+// too.
+//
+// Secrets are scrubbed from what is recorded: the Authorization, Cookie and
+// Set-Cookie headers always, and anything else passed to [Scrub].
+package vcr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/internal/modeflag"
+	"github.com/barry-hennessy/test/sweet/internal/testpath"
+)
+
+// RecordEnv is the environment variable that turns on record mode, for when
+// the -sweet.record flag can't be used, e.g. with `go test ./...`.
+const RecordEnv = "SWEET_RECORD"
+
+var record = modeflag.New("record", RecordEnv, "record HTTP interactions as cassettes instead of replaying them")
+
+// scrubbed replaces the secrets scrubbed from cassettes.
+const scrubbed = "<scrubbed>"
+
+// Cassette is the recording of a test's HTTP interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response it got.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// Body is a request or response body. It is recorded as a string, or as
+// base64 if it isn't UTF-8.
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	*b = decoded
+	return err
+}
+
+// Matcher reports whether a request the test made matches a recorded one,
+// after both were scrubbed.
+type Matcher func(got, recorded *Request) bool
+
+// MatchMethod matches requests with the same method.
+func MatchMethod(got, recorded *Request) bool {
+	return got.Method == recorded.Method
+}
+
+// MatchURL matches requests for the same URL, query included.
+func MatchURL(got, recorded *Request) bool {
+	return got.URL == recorded.URL
+}
+
+// MatchBody matches requests with the same body.
+func MatchBody(got, recorded *Request) bool {
+	return bytes.Equal(got.Body, recorded.Body)
+}
+
+// MatchHeader matches requests with the same values for the headers names.
+func MatchHeader(names ...string) Matcher {
+	return func(got, recorded *Request) bool {
+		for _, name := range names {
+			if strings.Join(got.Header.Values(name), "\n") != strings.Join(recorded.Header.Values(name), "\n") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Scrubber removes secrets, or volatile values, from an interaction before it
+// is recorded. Requests are scrubbed before they're matched too, with an
+// empty response.
+type Scrubber func(i *Interaction)
+
+// ScrubHeaders replaces the values of the headers names, in requests and
+// responses.
+func ScrubHeaders(names ...string) Scrubber {
+	return func(i *Interaction) {
+		for _, name := range names {
+			for _, h := range []http.Header{i.Request.Header, i.Response.Header} {
+				if h.Get(name) != "" {
+					h.Set(name, scrubbed)
+				}
+			}
+		}
+	}
+}
+
+// ScrubQuery replaces the values of the query parameters params in request
+// URLs.
+func ScrubQuery(params ...string) Scrubber {
+	return func(i *Interaction) {
+		u, err := url.Parse(i.Request.URL)
+		if err != nil {
+			return
+		}
+
+		q := u.Query()
+		for _, p := range params {
+			if q.Has(p) {
+				q.Set(p, scrubbed)
+			}
+		}
+		u.RawQuery = q.Encode()
+		i.Request.URL = u.String()
+	}
+}
+
+// ScrubBody replaces every match of re, in request and response bodies, with
+// repl, which can refer to submatches as in [regexp.Regexp.ReplaceAll].
+func ScrubBody(re *regexp.Regexp, repl string) Scrubber {
+	return func(i *Interaction) {
+		i.Request.Body = re.ReplaceAll(i.Request.Body, []byte(repl))
+		i.Response.Body = re.ReplaceAll(i.Response.Body, []byte(repl))
+	}
+}
+
+// defaultScrubber scrubs the credentials every cassette is scrubbed of.
+var defaultScrubber = ScrubHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie")
+
+// Recorder is an [http.RoundTripper] that records interactions to, or replays
+// them from, a cassette.
+type Recorder struct {
+	t         *testing.T
+	dir       string
+	name      string
+	matchers  []Matcher
+	scrubbers []Scrubber
+	transport http.RoundTripper
+	recording bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     map[*Interaction]bool
+}
+
+// Option configures the recorders made by [NewFactory].
+type Option func(*Recorder)
+
+// Dir sets the directory cassettes are kept in. It defaults to
+// testdata/cassettes.
+func Dir(dir string) Option {
+	return func(r *Recorder) {
+		r.dir = dir
+	}
+}
+
+// Name names the cassette, rather than naming it after the test, e.g. to
+// share one between tests.
+func Name(name string) Option {
+	return func(r *Recorder) {
+		r.name = name
+	}
+}
+
+// Match sets the matchers a request must match a recorded one by to be
+// replayed. It defaults to [MatchMethod] and [MatchURL].
+func Match(matchers ...Matcher) Option {
+	return func(r *Recorder) {
+		r.matchers = matchers
+	}
+}
+
+// Scrub registers scrubbers to run, in order, on every interaction.
+func Scrub(scrubbers ...Scrubber) Option {
+	return func(r *Recorder) {
+		r.scrubbers = append(r.scrubbers, scrubbers...)
+	}
+}
+
+// Transport sets the transport real requests are made with in record mode. It
+// defaults to [http.DefaultTransport].
+func Transport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// New is a [sweet.DepFactory] for an [http.Client] that replays the test's
+// cassette, or records it in record mode.
+func New(t *testing.T) *http.Client {
+	return NewFactory()(t)
+}
+
+// NewFactory creates a [sweet.DepFactory] for an [http.Client] that replays a
+// cassette, or records it in record mode, configured by opts. The client's
+// Transport is its [Recorder].
+func NewFactory(opts ...Option) sweet.DepFactory[*http.Client] {
+	return func(t *testing.T) *http.Client {
+		t.Helper()
+
+		r := &Recorder{
+			t:         t,
+			dir:       filepath.Join("testdata", "cassettes"),
+			name:      testpath.FromName(t.Name()),
+			matchers:  []Matcher{MatchMethod, MatchURL},
+			scrubbers: []Scrubber{defaultScrubber},
+			transport: http.DefaultTransport,
+			recording: Recording(),
+			used:      map[*Interaction]bool{},
+		}
+
+		for _, opt := range opts {
+			opt(r)
+		}
+
+		if r.recording {
+			t.Cleanup(r.save)
+		} else {
+			r.load()
+		}
+
+		return &http.Client{Transport: r}
+	}
+}
+
+// Path returns the cassette's file.
+func (r *Recorder) Path() string {
+	return filepath.Join(r.dir, r.name+".json")
+}
+
+func (r *Recorder) load() {
+	r.t.Helper()
+
+	path := r.Path()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		r.t.Fatalf("vcr: %s does not exist; run the test with -sweet.record to record it", path)
+	}
+	if err != nil {
+		r.t.Fatalf("vcr: reading %s: %s", path, err)
+	}
+
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		r.t.Fatalf("vcr: decoding %s: %s", path, err)
+	}
+}
+
+func (r *Recorder) save() {
+	path := r.Path()
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		r.t.Errorf("vcr: encoding %s: %s", path, err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Errorf("vcr: creating %s: %s", filepath.Dir(path), err)
+		return
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		r.t.Errorf("vcr: writing %s: %s", path, err)
+		return
+	}
+
+	r.t.Logf("vcr: recorded %d interaction(s) to %s", len(r.cassette.Interactions), path)
+}
+
+// RoundTrip makes req and records it in record mode, or answers it with the
+// first recorded interaction, not yet replayed, it matches.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("vcr: reading request body: %w", err)
+	}
+
+	i := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		},
+		Response: Response{Header: http.Header{}},
+	}
+	if i.Request.Header == nil {
+		i.Request.Header = http.Header{}
+	}
+
+	if r.recording {
+		return r.record(req, i)
+	}
+	return r.replay(req, i)
+}
+
+func (r *Recorder) record(req *http.Request, i *Interaction) (*http.Response, error) {
+	// The body of req was read, and RoundTrip mustn't change req, so a copy
+	// is sent with the body that was read.
+	out := req.Clone(req.Context())
+	if i.Request.Body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(i.Request.Body))
+	}
+
+	resp, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("vcr: reading response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.Request = req
+
+	i.Response = Response{
+		Status: resp.StatusCode,
+		Header: resp.Header.Clone(),
+		Body:   body,
+	}
+	r.scrub(i)
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, i *Interaction) (*http.Response, error) {
+	r.scrub(i)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, recorded := range r.cassette.Interactions {
+		if r.used[recorded] || !r.matches(&i.Request, &recorded.Request) {
+			continue
+		}
+		r.used[recorded] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.Response.Status, http.StatusText(recorded.Response.Status)),
+			StatusCode:    recorded.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(recorded.Response.Body)),
+			ContentLength: int64(len(recorded.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("vcr: no interaction left in %s matches %s %s; run the test with -sweet.record to record it", r.Path(), i.Request.Method, i.Request.URL)
+}
+
+func (r *Recorder) matches(got, recorded *Request) bool {
+	for _, match := range r.matchers {
+		if !match(got, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Recorder) scrub(i *Interaction) {
+	for _, scrub := range r.scrubbers {
+		scrub(i)
+	}
+}
+
+// readAll reads and closes body, which may be nil.
+func readAll(body io.ReadCloser) ([]byte, error) {
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+// Recording reports whether cassettes should be recorded rather than
+// replayed, i.e. whether -sweet.record, -record or [RecordEnv] is set.
+func Recording() bool {
+	return record.On()
+}
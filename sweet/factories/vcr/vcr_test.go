@@ -0,0 +1,210 @@
+package vcr_test
+
+import (
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/vcr"
+)
+
+// record is the package's own -record flag, which vcr mustn't clash with.
+var record = flag.Bool("record", false, "record the cassettes")
+
+// api stands in for a third party API: it greets whoever calls it, as long
+// as they have a token.
+func api(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=abc123")
+		io.WriteString(w, "hello "+r.URL.Query().Get("name")+string(body)+", your key is k-12345")
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer s3cret")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.Status + ": " + string(body)
+}
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	server := api(t)
+	url := server.URL + "/greet?name=ann&api_key=k-12345"
+
+	factory := vcr.NewFactory(
+		vcr.Dir(dir),
+		vcr.Name("greet"),
+		vcr.Scrub(
+			vcr.ScrubQuery("api_key"),
+			vcr.ScrubBody(regexp.MustCompile(`k-\d+`), "k-XXX"),
+		),
+	)
+
+	var recorded string
+	t.Run("record", func(t *testing.T) {
+		t.Setenv(vcr.RecordEnv, "1")
+
+		sweet.Run(t, "greet", factory, func(t *testing.T, client *http.Client) {
+			recorded = get(t, client, url)
+		})
+	})
+
+	if recorded != "200 OK: hello ann, your key is k-12345" {
+		t.Errorf("expected the real response while recording, got %q", recorded)
+	}
+
+	cassette, err := os.ReadFile(filepath.Join(dir, "greet.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cret", "abc123", "k-12345"} {
+		if strings.Contains(string(cassette), secret) {
+			t.Errorf("expected %s to be scrubbed from the cassette:\n%s", secret, cassette)
+		}
+	}
+
+	server.Close()
+
+	t.Run("replay", func(t *testing.T) {
+		sweet.Run(t, "greet", factory, func(t *testing.T, client *http.Client) {
+			if got := get(t, client, url); got != "200 OK: hello ann, your key is k-XXX" {
+				t.Errorf("expected the scrubbed recorded response, got %q", got)
+			}
+
+			// Each recorded interaction is replayed once.
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "no interaction left") {
+				t.Errorf("expected no interaction left to replay, got %v", err)
+			}
+		})
+	})
+}
+
+func TestRecorder_match(t *testing.T) {
+	dir := t.TempDir()
+	server := api(t)
+
+	post := func(t *testing.T, client *http.Client, body string) (string, error) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/greet", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+
+		got, err := io.ReadAll(resp.Body)
+		return string(got), err
+	}
+
+	factory := vcr.NewFactory(vcr.Dir(dir), vcr.Name("posts"), vcr.Match(vcr.MatchMethod, vcr.MatchURL, vcr.MatchBody))
+
+	t.Run("posts", func(t *testing.T) {
+		t.Setenv(vcr.RecordEnv, "1")
+
+		sweet.Run(t, "posts", factory, func(t *testing.T, client *http.Client) {
+			for _, body := range []string{" and bob", " and cat"} {
+				if _, err := post(t, client, body); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	})
+
+	server.Close()
+
+	sweet.Run(t, "posts", factory, func(t *testing.T, client *http.Client) {
+		got, err := post(t, client, " and cat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "hello  and cat, your key is k-12345" {
+			t.Errorf("expected the response to the request with the same body, got %q", got)
+		}
+
+		if _, err := post(t, client, " and dan"); err == nil {
+			t.Error("expected a request with a body that wasn't recorded not to match")
+		}
+	})
+}
+
+func TestBody(t *testing.T) {
+	for _, body := range []vcr.Body{vcr.Body("text"), vcr.Body{0xff, 0x00, 0xfe}} {
+		data, err := body.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got vcr.Body
+		if err := got.UnmarshalJSON(data); err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(body) {
+			t.Errorf("%q round tripped as %q, via %s", body, got, data)
+		}
+	}
+}
+
+func TestRecording(t *testing.T) {
+	if vcr.Recording() {
+		t.Skip("the cassettes are being recorded")
+	}
+
+	if err := flag.Set("sweet.record", "true"); err != nil {
+		t.Fatal(err)
+	}
+	if !vcr.Recording() {
+		t.Error("expected -sweet.record to turn record mode on")
+	}
+	flag.Set("sweet.record", "false")
+
+	flag.Set("record", "true")
+	defer flag.Set("record", "false")
+	if !vcr.Recording() || !*record {
+		t.Error("expected the package's own -record to turn record mode on")
+	}
+}
+
+func TestNew(t *testing.T) {
+	// Replays testdata/cassettes/TestNew/greets.json; api.example.com is never
+	// called.
+	sweet.Run(t, "greets", vcr.New, func(t *testing.T, client *http.Client) {
+		if got := get(t, client, "https://api.example.com/greet?name=ann"); got != "200 OK: hello ann" {
+			t.Errorf("expected the recorded response, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package sweet_test
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// failingEnv marks the child process run by runFailing.
+const failingEnv = "SWEET_TEST_FAILING"
+
+// runFailing runs the test named name in a child process, where it is
+// expected to fail, and returns its output. It lets us check what sweet does
+// when a test fails without failing the test checking it.
+//
+// Tests run this way must call skipUnlessFailing first.
+func runFailing(t *testing.T, name string, env ...string) string {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^"+name+"$", "-test.v", "-test.count=1")
+	cmd.Env = append(append(os.Environ(), failingEnv+"=1"), env...)
+
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("expected %s to fail, got %v:\n%s", name, err, out)
+	}
+
+	return string(out)
+}
+
+// skipUnlessFailing skips tests that are only meant to be run, and fail, via
+// runFailing.
+func skipUnlessFailing(t *testing.T) {
+	if os.Getenv(failingEnv) == "" {
+		t.Skip("only run by runFailing")
+	}
+}
@@ -0,0 +1,282 @@
+// Package gen provides generators of random values, for sweet.Check, that
+// shrink the values they generate when they make a property fail.
+//
+// Generators compose: shrinking a value made with [Map], [SliceOf] or any of
+// the others shrinks the values it was made from.
+//
+//	type order struct {
+//		items    []string
+//		quantity int
+//	}
+//
+//	orders := gen.Map2(gen.SliceOf(gen.String()), gen.Int(1, 100),
+//		func(items []string, quantity int) order {
+//			return order{items: items, quantity: quantity}
+//		})
+package gen
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Gen generates random values of T.
+//
+// size grows with each value generated for a property, from 0, so early
+// values are small. Generators of collections bound their length by it.
+type Gen[T any] func(r *rand.Rand, size int) Tree[T]
+
+// Tree is a generated value, along with the smaller values it can shrink to.
+type Tree[T any] struct {
+	Value T
+
+	shrinks func() []Tree[T]
+}
+
+// Shrinks returns the values the tree's value shrinks to, most shrunk first.
+// Each one shrinks in turn.
+func (t Tree[T]) Shrinks() []Tree[T] {
+	if t.shrinks == nil {
+		return nil
+	}
+	return t.shrinks()
+}
+
+// New creates a generator from generate, which generates a value, and
+// shrink, which returns the values a value shrinks to, most shrunk first.
+// shrink may be nil for values that don't shrink.
+func New[T any](generate func(r *rand.Rand, size int) T, shrink func(v T) []T) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		return newTree(generate(r, size), shrink)
+	}
+}
+
+func newTree[T any](v T, shrink func(v T) []T) Tree[T] {
+	if shrink == nil {
+		return Tree[T]{Value: v}
+	}
+
+	return Tree[T]{
+		Value: v,
+		shrinks: func() []Tree[T] {
+			smaller := shrink(v)
+			trees := make([]Tree[T], 0, len(smaller))
+			for _, s := range smaller {
+				trees = append(trees, newTree(s, shrink))
+			}
+			return trees
+		},
+	}
+}
+
+// Const generates v. It doesn't shrink.
+func Const[T any](v T) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		return Tree[T]{Value: v}
+	}
+}
+
+// Int generates ints from min to max, inclusive. They shrink towards the one
+// closest to zero.
+func Int(min, max int) Gen[int] {
+	if min > max {
+		panic("gen: Int called with min > max")
+	}
+
+	target := 0
+	switch {
+	case min > 0:
+		target = min
+	case max < 0:
+		target = max
+	}
+
+	return New(func(r *rand.Rand, size int) int {
+		span := uint64(max - min)
+		if span == math.MaxUint64 {
+			return int(r.Uint64())
+		}
+		return min + int(r.Uint64()%(span+1))
+	}, func(v int) []int {
+		return shrinkInt(v, target)
+	})
+}
+
+// shrinkInt returns the values between target and v, from target, halving
+// the distance to v each time.
+func shrinkInt(v, target int) []int {
+	var smaller []int
+	for d := v - target; d != 0; d /= 2 {
+		smaller = append(smaller, v-d)
+	}
+	return smaller
+}
+
+// Bool generates bools. They shrink to false.
+func Bool() Gen[bool] {
+	return New(func(r *rand.Rand, size int) bool {
+		return r.Intn(2) == 1
+	}, func(v bool) []bool {
+		if v {
+			return []bool{false}
+		}
+		return nil
+	})
+}
+
+// OneOf generates one of values. They shrink towards the first.
+func OneOf[T any](values ...T) Gen[T] {
+	if len(values) == 0 {
+		panic("gen: OneOf called without values")
+	}
+
+	return Map(Int(0, len(values)-1), func(i int) T {
+		return values[i]
+	})
+}
+
+// Rune generates runes from alphabet. They shrink towards its first rune.
+func Rune(alphabet string) Gen[rune] {
+	return OneOf([]rune(alphabet)...)
+}
+
+// alphanumeric is the alphabet of String.
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// String generates alphanumeric strings up to size long. They shrink to
+// shorter strings, of earlier letters.
+func String() Gen[string] {
+	return StringOf(Rune(alphanumeric))
+}
+
+// StringOf generates strings of runes from runes, up to size long.
+func StringOf(runes Gen[rune]) Gen[string] {
+	return Map(SliceOf(runes), func(rs []rune) string {
+		return string(rs)
+	})
+}
+
+// SliceOf generates slices of elements from elem, up to size long. They
+// shrink by dropping elements, then by shrinking them.
+func SliceOf[T any](elem Gen[T]) Gen[[]T] {
+	return func(r *rand.Rand, size int) Tree[[]T] {
+		elems := make([]Tree[T], r.Intn(size+1))
+		for i := range elems {
+			elems[i] = elem(r, size)
+		}
+		return sliceTree(elems)
+	}
+}
+
+func sliceTree[T any](elems []Tree[T]) Tree[[]T] {
+	values := make([]T, len(elems))
+	for i, e := range elems {
+		values[i] = e.Value
+	}
+
+	return Tree[[]T]{
+		Value: values,
+		shrinks: func() []Tree[[]T] {
+			var trees []Tree[[]T]
+
+			// Drop runs of elements, the whole slice first, then halves and
+			// so on down to single elements.
+			for n := len(elems); n > 0; n /= 2 {
+				for i := 0; i+n <= len(elems); i += n {
+					dropped := append(append([]Tree[T]{}, elems[:i]...), elems[i+n:]...)
+					trees = append(trees, sliceTree(dropped))
+				}
+			}
+
+			for i, e := range elems {
+				for _, s := range e.Shrinks() {
+					shrunk := append([]Tree[T]{}, elems...)
+					shrunk[i] = s
+					trees = append(trees, sliceTree(shrunk))
+				}
+			}
+
+			return trees
+		},
+	}
+}
+
+// Map generates values of g passed through f. They shrink as the values of g
+// do.
+func Map[T, U any](g Gen[T], f func(T) U) Gen[U] {
+	return func(r *rand.Rand, size int) Tree[U] {
+		return mapTree(g(r, size), f)
+	}
+}
+
+func mapTree[T, U any](t Tree[T], f func(T) U) Tree[U] {
+	return Tree[U]{
+		Value: f(t.Value),
+		shrinks: func() []Tree[U] {
+			var trees []Tree[U]
+			for _, s := range t.Shrinks() {
+				trees = append(trees, mapTree(s, f))
+			}
+			return trees
+		},
+	}
+}
+
+// Map2 generates values of f, from the values of a and b. They shrink as the
+// values of a, then b, do.
+func Map2[A, B, T any](a Gen[A], b Gen[B], f func(A, B) T) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		return map2Tree(a(r, size), b(r, size), f)
+	}
+}
+
+func map2Tree[A, B, T any](a Tree[A], b Tree[B], f func(A, B) T) Tree[T] {
+	return Tree[T]{
+		Value: f(a.Value, b.Value),
+		shrinks: func() []Tree[T] {
+			var trees []Tree[T]
+			for _, s := range a.Shrinks() {
+				trees = append(trees, map2Tree(s, b, f))
+			}
+			for _, s := range b.Shrinks() {
+				trees = append(trees, map2Tree(a, s, f))
+			}
+			return trees
+		},
+	}
+}
+
+// maxFilterTries is how many values Filter generates looking for one to
+// keep.
+const maxFilterTries = 100
+
+// Filter generates the values of g that keep returns true for. They shrink
+// to values it keeps too.
+//
+// Filter panics if g generates nothing it keeps in a hundred tries; make g
+// generate fewer values it doesn't keep.
+func Filter[T any](g Gen[T], keep func(T) bool) Gen[T] {
+	return func(r *rand.Rand, size int) Tree[T] {
+		for i := 0; i < maxFilterTries; i++ {
+			if t := g(r, size); keep(t.Value) {
+				return filterTree(t, keep)
+			}
+		}
+		panic("gen: Filter found no value to keep")
+	}
+}
+
+func filterTree[T any](t Tree[T], keep func(T) bool) Tree[T] {
+	return Tree[T]{
+		Value: t.Value,
+		shrinks: func() []Tree[T] {
+			var trees []Tree[T]
+			for _, s := range t.Shrinks() {
+				if keep(s.Value) {
+					trees = append(trees, filterTree(s, keep))
+				}
+			}
+			return trees
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package gen_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/gen"
+)
+
+// smallest shrinks t to the smallest value for which fails holds, the way
+// sweet.Check does.
+func smallest[T any](t gen.Tree[T], fails func(T) bool) T {
+	for shrunk := true; shrunk; {
+		shrunk = false
+		for _, s := range t.Shrinks() {
+			if fails(s.Value) {
+				t, shrunk = s, true
+				break
+			}
+		}
+	}
+	return t.Value
+}
+
+func TestInt(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, tc := range []struct {
+		name     string
+		min, max int
+		target   int
+	}{
+		{"around zero", -50, 50, 0},
+		{"positive", 10, 20, 10},
+		{"negative", -20, -10, -10},
+	} {
+		g := gen.Int(tc.min, tc.max)
+		for i := 0; i < 100; i++ {
+			tree := g(r, i)
+			if tree.Value < tc.min || tree.Value > tc.max {
+				t.Fatalf("%s: generated %d, outside [%d, %d]", tc.name, tree.Value, tc.min, tc.max)
+			}
+
+			if got := smallest(tree, func(int) bool { return true }); got != tc.target {
+				t.Errorf("%s: %d shrank to %d, want %d", tc.name, tree.Value, got, tc.target)
+			}
+		}
+	}
+
+	tree := gen.Int(0, 1000)(r, 0)
+	for tree.Value < 100 {
+		tree = gen.Int(0, 1000)(r, 0)
+	}
+	if got := smallest(tree, func(v int) bool { return v >= 37 }); got != 37 {
+		t.Errorf("%d shrank to %d, want 37", tree.Value, got)
+	}
+}
+
+func TestSliceOf(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	g := gen.SliceOf(gen.Int(0, 100))
+
+	for i := 0; i < 100; i++ {
+		tree := g(r, 10)
+		if len(tree.Value) > 10 {
+			t.Fatalf("generated %d elements, more than the size", len(tree.Value))
+		}
+	}
+
+	tree := g(r, 50)
+	for !containsOver(tree.Value, 20) {
+		tree = g(r, 50)
+	}
+	got := smallest(tree, func(v []int) bool { return containsOver(v, 20) })
+	if !reflect.DeepEqual(got, []int{21}) {
+		t.Errorf("%v shrank to %v, want [21]", tree.Value, got)
+	}
+}
+
+func containsOver(values []int, n int) bool {
+	for _, v := range values {
+		if v > n {
+			return true
+		}
+	}
+	return false
+}
+
+func TestString(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	tree := gen.String()(r, 20)
+	for !strings.ContainsAny(tree.Value, "XYZ") {
+		tree = gen.String()(r, 20)
+	}
+	if got := smallest(tree, func(s string) bool { return strings.ContainsAny(s, "XYZ") }); got != "X" {
+		t.Errorf("%q shrank to %q, want %q", tree.Value, got, "X")
+	}
+}
+
+func TestMap2(t *testing.T) {
+	type pair struct{ a, b int }
+	g := gen.Map2(gen.Int(0, 100), gen.Int(0, 100), func(a, b int) pair {
+		return pair{a, b}
+	})
+
+	r := rand.New(rand.NewSource(1))
+	tree := g(r, 0)
+	for tree.Value.a+tree.Value.b < 50 {
+		tree = g(r, 0)
+	}
+	got := smallest(tree, func(p pair) bool { return p.a+p.b >= 50 })
+	// Neither can shrink any further without the sum dropping below 50.
+	if got.a+got.b != 50 {
+		t.Errorf("%+v shrank to %+v, want them to add up to 50", tree.Value, got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	even := gen.Filter(gen.Int(0, 100), func(v int) bool { return v%2 == 0 })
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		tree := even(r, i)
+		if tree.Value%2 != 0 {
+			t.Fatalf("generated %d, which was filtered out", tree.Value)
+		}
+		for _, s := range tree.Shrinks() {
+			if s.Value%2 != 0 {
+				t.Fatalf("%d shrinks to %d, which was filtered out", tree.Value, s.Value)
+			}
+		}
+	}
+}
+
+func TestGen_deterministic(t *testing.T) {
+	g := gen.SliceOf(gen.OneOf("a", "b", "c"))
+
+	first := g(rand.New(rand.NewSource(7)), 10).Value
+	second := g(rand.New(rand.NewSource(7)), 10).Value
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected the same seed to generate the same values, got %v and %v", first, second)
+	}
+}
@@ -0,0 +1,229 @@
+package sweet
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// kept holds, for each test, the values [NoClose] factories made for it.
+var kept sync.Map
+
+// Verifier is implemented by dependencies that check expectations once the
+// test is over, e.g. mocks.
+//
+// [Run] calls Verify when the test body returns, even if it failed, and before
+// any cleanup runs.
+type Verifier interface {
+	Verify(t *testing.T)
+}
+
+// Resetter is implemented by dependencies that need putting back into a known
+// state before they are used, e.g. ones shared between tests.
+//
+// [Run] calls Reset after the [DepFactory] returns and before the test body
+// runs.
+type Resetter interface {
+	Reset(t *testing.T)
+}
+
+// NoClose wraps factory so that [Run] doesn't close the value it returns,
+// e.g. a client shared between tests and closed elsewhere. It also applies
+// when the value is a field of deps composed from factory.
+func NoClose[D any](factory DepFactory[D]) DepFactory[D] {
+	return func(t *testing.T) D {
+		t.Helper()
+
+		d := factory(t)
+		if key, ok := valueKey(d); ok {
+			v, loaded := kept.LoadOrStore(t, &keptValues{keys: map[any]bool{}})
+			if !loaded {
+				t.Cleanup(func() { kept.Delete(t) })
+			}
+
+			kv := v.(*keptValues)
+			kv.mu.Lock()
+			kv.keys[key] = true
+			kv.mu.Unlock()
+		}
+
+		return d
+	}
+}
+
+type keptValues struct {
+	mu   sync.Mutex
+	keys map[any]bool
+}
+
+// isKeptOpen reports whether v was made for t by a [NoClose] factory.
+func isKeptOpen(t *testing.T, v any) bool {
+	key, ok := valueKey(v)
+	if !ok {
+		return false
+	}
+
+	kv, ok := kept.Load(t)
+	if !ok {
+		return false
+	}
+
+	kv.(*keptValues).mu.Lock()
+	defer kv.(*keptValues).mu.Unlock()
+	return kv.(*keptValues).keys[key]
+}
+
+// valueKey returns a key identifying v, if it is a reference: a pointer, map
+// or channel. Other values can't be told apart from copies of themselves, and
+// not every comparable type is safe to hash, e.g. a struct with an interface
+// field holding a map.
+func valueKey(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		if rv.IsNil() {
+			return nil, false
+		}
+		return refKey{rv.Type(), rv.Pointer()}, true
+	default:
+		return nil, false
+	}
+}
+
+type refKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// hooks are the lifecycle methods [Run] found on a set of dependencies.
+type hooks struct {
+	closers   []io.Closer
+	verifiers []Verifier
+	resetters []Resetter
+}
+
+// findHooks looks for [io.Closer], [Verifier] and [Resetter] implementations
+// on d and, if d is a struct or a pointer to one, on its exported fields.
+//
+// If d implements one of the interfaces itself its fields are not checked for
+// that interface; d is taken to look after them. Fields opt out with a
+// `sweet:"-"` tag, or of single hooks with `sweet:"noclose"`, `sweet:"noverify"`
+// or `sweet:"noreset"`.
+//
+// Values shared by a [PackageDep], or made by a [NoClose] factory, are never
+// closed; they outlive the test.
+func findHooks(t *testing.T, d any) hooks {
+	h := hooks{}
+	if d == nil || isNil(reflect.ValueOf(d)) {
+		return h
+	}
+
+	closer, isCloser := d.(io.Closer)
+	verifier, isVerifier := d.(Verifier)
+	resetter, isResetter := d.(Resetter)
+
+	if isCloser && !isPackageValue(d) && !isKeptOpen(t, d) {
+		h.closers = append(h.closers, closer)
+	}
+	if isVerifier {
+		h.verifiers = append(h.verifiers, verifier)
+	}
+	if isResetter {
+		h.resetters = append(h.resetters, resetter)
+	}
+
+	v := reflect.ValueOf(d)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return h
+	}
+
+	seen := map[any]bool{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("sweet")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if isNil(fv) {
+			continue
+		}
+
+		value := fv.Interface()
+		if key, ok := valueKey(value); ok {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+
+		if c, ok := value.(io.Closer); ok && !isCloser && !hasOption(tag, "noclose") && !isPackageValue(value) && !isKeptOpen(t, value) {
+			h.closers = append(h.closers, c)
+		}
+		if vr, ok := value.(Verifier); ok && !isVerifier && !hasOption(tag, "noverify") {
+			h.verifiers = append(h.verifiers, vr)
+		}
+		if r, ok := value.(Resetter); ok && !isResetter && !hasOption(tag, "noreset") {
+			h.resetters = append(h.resetters, r)
+		}
+	}
+
+	return h
+}
+
+// reset calls every [Resetter] and registers every [io.Closer] to be closed
+// when the test ends. Closers are closed in reverse order, like other
+// cleanups.
+func (h hooks) reset(t *testing.T) {
+	t.Helper()
+
+	for _, c := range h.closers {
+		c := c
+		t.Cleanup(func() {
+			// Factories may well have closed it already, so a failure here
+			// doesn't fail the test.
+			if err := c.Close(); err != nil {
+				t.Logf("sweet: closing %T: %s", c, err)
+			}
+		})
+	}
+
+	for _, r := range h.resetters {
+		r.Reset(t)
+	}
+}
+
+// verify calls every [Verifier].
+func (h hooks) verify(t *testing.T) {
+	t.Helper()
+
+	for _, v := range h.verifiers {
+		v.Verify(t)
+	}
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+func hasOption(tag, option string) bool {
+	for _, o := range strings.Split(tag, ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+
+	return false
+}
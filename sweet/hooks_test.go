@@ -0,0 +1,157 @@
+package sweet_test
+
+import (
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type lifecycle struct {
+	events *[]string
+	name   string
+}
+
+func (l *lifecycle) Close() error {
+	*l.events = append(*l.events, l.name+" closed")
+	return nil
+}
+
+func (l *lifecycle) Verify(t *testing.T) {
+	*l.events = append(*l.events, l.name+" verified")
+}
+
+func (l *lifecycle) Reset(t *testing.T) {
+	*l.events = append(*l.events, l.name+" reset")
+}
+
+type lifecycleDeps struct {
+	A        *lifecycle
+	B        *lifecycle
+	Skipped  *lifecycle `sweet:"-"`
+	NoClose  *lifecycle `sweet:"noclose,noreset"`
+	Missing  *lifecycle
+	internal *lifecycle
+}
+
+func TestRun_hooks(t *testing.T) {
+	t.Run("are called on fields at the right time", func(t *testing.T) {
+		events := []string{}
+		factory := func(t *testing.T) lifecycleDeps {
+			return lifecycleDeps{
+				A:        &lifecycle{&events, "a"},
+				B:        &lifecycle{&events, "b"},
+				Skipped:  &lifecycle{&events, "skipped"},
+				NoClose:  &lifecycle{&events, "noclose"},
+				internal: &lifecycle{&events, "internal"},
+			}
+		}
+
+		sweet.Run(t, "test", factory, func(t *testing.T, d lifecycleDeps) {
+			events = append(events, "test")
+		})
+
+		expected := []string{
+			"a reset", "b reset",
+			"test",
+			"a verified", "b verified", "noclose verified",
+			"b closed", "a closed",
+		}
+
+		assertEvents(t, expected, events)
+	})
+
+	t.Run("are left to the dependency if it implements them", func(t *testing.T) {
+		events := []string{}
+		factory := func(t *testing.T) *lifecycle {
+			return &lifecycle{&events, "top"}
+		}
+
+		sweet.Run(t, "test", factory, func(t *testing.T, d *lifecycle) {})
+
+		assertEvents(t, []string{"top reset", "top verified", "top closed"}, events)
+	})
+
+	t.Run("run after the factory's cleanups are registered", func(t *testing.T) {
+		events := []string{}
+		factory := func(t *testing.T) *lifecycle {
+			t.Cleanup(func() {
+				events = append(events, "factory cleanup")
+			})
+			return &lifecycle{&events, "top"}
+		}
+
+		sweet.Run(t, "test", factory, func(t *testing.T, d *lifecycle) {})
+
+		assertEvents(t, []string{"top reset", "top verified", "top closed", "factory cleanup"}, events)
+	})
+
+	t.Run("are called once for a value in several fields", func(t *testing.T) {
+		events := []string{}
+		factory := func(t *testing.T) lifecycleDeps {
+			shared := &lifecycle{&events, "shared"}
+			return lifecycleDeps{A: shared, B: shared}
+		}
+
+		sweet.Run(t, "test", factory, func(t *testing.T, d lifecycleDeps) {})
+
+		assertEvents(t, []string{"shared reset", "shared verified", "shared closed"}, events)
+	})
+
+	t.Run("skip fields holding unhashable values", func(t *testing.T) {
+		type deps struct {
+			Config any
+			Hooks  any
+			L      *lifecycle
+		}
+
+		events := []string{}
+		factory := func(t *testing.T) deps {
+			return deps{
+				Config: map[string]string{"a": "b"},
+				Hooks:  []func(){func() {}},
+				L:      &lifecycle{&events, "l"},
+			}
+		}
+
+		sweet.Run(t, "test", factory, func(t *testing.T, d deps) {})
+
+		assertEvents(t, []string{"l reset", "l verified", "l closed"}, events)
+	})
+
+	t.Run("don't close values from NoClose factories", func(t *testing.T) {
+		events := []string{}
+		shared := &lifecycle{&events, "shared"}
+		sharedFactory := sweet.NoClose(func(t *testing.T) *lifecycle {
+			return shared
+		})
+
+		sweet.Run(t, "top level", sharedFactory, func(t *testing.T, d *lifecycle) {})
+		assertEvents(t, []string{"shared reset", "shared verified"}, events)
+
+		events = events[:0]
+		composed := func(t *testing.T) lifecycleDeps {
+			return lifecycleDeps{
+				A: sharedFactory(t),
+				B: &lifecycle{&events, "b"},
+			}
+		}
+
+		sweet.Run(t, "composed", composed, func(t *testing.T, d lifecycleDeps) {})
+		assertEvents(t, []string{"shared reset", "b reset", "shared verified", "b verified", "b closed"}, events)
+	})
+}
+
+func assertEvents(t *testing.T, expected, got []string) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected, got)
+			return
+		}
+	}
+}
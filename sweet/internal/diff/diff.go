@@ -0,0 +1,156 @@
+// Package diff produces line based unified diffs for test failure messages.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns a unified diff turning want into got, or "" if they are
+// equal.
+func Unified(wantName, gotName string, want, got []byte) string {
+	if string(want) == string(got) {
+		return ""
+	}
+
+	ops := edits(lines(string(want)), lines(string(got)))
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "--- %s\n+++ %s\n", wantName, gotName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change and the extent of the hunk around it.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		from := max(first-context, start)
+		to := first
+		for unchanged := 0; to < len(ops) && unchanged <= 2*context; to++ {
+			if ops[to].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		// Trim trailing context down to size.
+		for to > first && ops[to-1].kind == ' ' && trailing(ops[:to]) > context {
+			to--
+		}
+
+		writeHunk(b, ops, from, to)
+		start = to
+	}
+
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, ops []op, from, to int) {
+	wantStart, gotStart := 1, 1
+	for _, o := range ops[:from] {
+		if o.kind != '+' {
+			wantStart++
+		}
+		if o.kind != '-' {
+			gotStart++
+		}
+	}
+
+	wantLen, gotLen := 0, 0
+	for _, o := range ops[from:to] {
+		if o.kind != '+' {
+			wantLen++
+		}
+		if o.kind != '-' {
+			gotLen++
+		}
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", wantStart, wantLen, gotStart, gotLen)
+	for _, o := range ops[from:to] {
+		fmt.Fprintf(b, "%c%s\n", o.kind, o.line)
+	}
+}
+
+// trailing counts the unchanged lines at the end of ops.
+func trailing(ops []op) int {
+	n := 0
+	for i := len(ops) - 1; i >= 0 && ops[i].kind == ' '; i-- {
+		n++
+	}
+
+	return n
+}
+
+// edits returns the shortest edit script from a to b, using the longest
+// common subsequence of their lines.
+func edits(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := []op{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+
+	return ops
+}
+
+func lines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
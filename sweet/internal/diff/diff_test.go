@@ -0,0 +1,62 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/internal/diff"
+)
+
+func TestUnified(t *testing.T) {
+	t.Run("equal inputs have no diff", func(t *testing.T) {
+		if d := diff.Unified("want", "got", []byte("a\nb\n"), []byte("a\nb\n")); d != "" {
+			t.Errorf("expected no diff, got:\n%s", d)
+		}
+	})
+
+	t.Run("changes are shown with context", func(t *testing.T) {
+		want := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n")
+		got := []byte("1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n")
+
+		expected := `--- want
++++ got
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`
+
+		if d := diff.Unified("want", "got", want, got); d != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, d)
+		}
+	})
+
+	t.Run("distant changes get their own hunks", func(t *testing.T) {
+		want := []byte("a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n")
+		got := []byte("A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n")
+
+		expected := `--- want
++++ got
+@@ -1,4 +1,4 @@
+-a
++A
+ 1
+ 2
+ 3
+@@ -7,4 +7,4 @@
+ 6
+ 7
+ 8
+-b
++B
+`
+
+		if d := diff.Unified("want", "got", want, got); d != expected {
+			t.Errorf("expected:\n%s\ngot:\n%s", expected, d)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+// Package modeflag provides the switches turning on the modes of factories,
+// like updating golden files, from the command line or the environment.
+package modeflag
+
+import (
+	"flag"
+	"os"
+)
+
+// Prefix namespaces the flags registered by [New], so they can't clash with
+// the flags of the test packages using them.
+const Prefix = "sweet."
+
+// Mode is a mode of a factory, turned on with a flag or an environment
+// variable.
+type Mode struct {
+	name string
+	env  string
+}
+
+// New registers the flag -sweet.<name>, on [flag.CommandLine], turning on a
+// mode that env turns on too, e.g. for `go test ./...` where not every
+// package knows the flag. It's for package level variables, so the flag is
+// registered before the flags are parsed.
+func New(name, env, usage string) *Mode {
+	flag.Bool(Prefix+name, false, usage)
+	return &Mode{name: name, env: env}
+}
+
+// On reports whether the mode is on: its environment variable is set to
+// anything but "0", or -sweet.<name> is set. So is a boolean flag -<name>, if
+// the test package defined one itself, as is common for -update.
+func (m *Mode) On() bool {
+	if v := os.Getenv(m.env); v != "" && v != "0" {
+		return true
+	}
+
+	return isSet(Prefix+m.name) || isSet(m.name)
+}
+
+// isSet reports whether the boolean flag name, of [flag.CommandLine], is set.
+func isSet(name string) bool {
+	f := flag.Lookup(name)
+	if f == nil {
+		return false
+	}
+
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+
+	on, _ := getter.Get().(bool)
+	return on
+}
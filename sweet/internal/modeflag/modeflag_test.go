@@ -0,0 +1,63 @@
+package modeflag_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/internal/modeflag"
+)
+
+// The test package's own flag, which a mode of the same name must not clash
+// with.
+var refresh = flag.Bool("refresh", false, "a flag of the test package")
+
+var mode = modeflag.New("refresh", "MODEFLAG_TEST_REFRESH", "refresh things")
+
+func TestMode(t *testing.T) {
+	set := func(t *testing.T, name, value string) {
+		t.Helper()
+
+		if err := flag.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { flag.Set(name, "false") })
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		if mode.On() {
+			t.Error("expected the mode to be off")
+		}
+	})
+
+	t.Run("the namespaced flag", func(t *testing.T) {
+		set(t, "sweet.refresh", "true")
+
+		if !mode.On() {
+			t.Error("expected -sweet.refresh to turn the mode on")
+		}
+	})
+
+	t.Run("the package's own flag", func(t *testing.T) {
+		set(t, "refresh", "true")
+
+		if !mode.On() || !*refresh {
+			t.Error("expected -refresh to turn the mode on")
+		}
+	})
+
+	t.Run("the environment", func(t *testing.T) {
+		t.Setenv("MODEFLAG_TEST_REFRESH", "1")
+
+		if !mode.On() {
+			t.Error("expected the environment variable to turn the mode on")
+		}
+	})
+
+	t.Run("the environment set to 0", func(t *testing.T) {
+		t.Setenv("MODEFLAG_TEST_REFRESH", "0")
+
+		if mode.On() {
+			t.Error("expected the mode to be off")
+		}
+	})
+}
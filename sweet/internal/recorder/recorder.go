@@ -0,0 +1,30 @@
+// Package recorder provides a [testing.TB] recording the errors reported to
+// it, to test the checks factories run when a test ends without failing the
+// test.
+package recorder
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TB captures the errors reported to it instead of failing the test. Only
+// Helper, Name and Errorf may be called.
+type TB struct {
+	testing.TB
+
+	// TestName is returned by Name.
+	TestName string
+	// Errors are the errors reported, formatted.
+	Errors []string
+}
+
+func (r *TB) Helper() {}
+
+func (r *TB) Name() string {
+	return r.TestName
+}
+
+func (r *TB) Errorf(format string, args ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,26 @@
+package recorder_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/internal/recorder"
+)
+
+func TestTB(t *testing.T) {
+	r := &recorder.TB{TestName: "TestFoo/bar"}
+
+	var tb testing.TB = r
+	tb.Helper()
+	tb.Errorf("%d timer(s) never fired", 2)
+	tb.Errorf("unexpected request")
+
+	if got := tb.Name(); got != "TestFoo/bar" {
+		t.Errorf("expected the name %q, got %q", "TestFoo/bar", got)
+	}
+
+	expected := []string{"2 timer(s) never fired", "unexpected request"}
+	if !reflect.DeepEqual(r.Errors, expected) {
+		t.Errorf("expected the errors %q, got %q", expected, r.Errors)
+	}
+}
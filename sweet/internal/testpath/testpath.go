@@ -0,0 +1,32 @@
+// Package testpath turns test names into file paths.
+package testpath
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// FromName returns a relative file path for a test name, as returned by
+// [testing.T.Name]. Each subtest becomes a directory and characters that are
+// not safe in file names on every OS are replaced with underscores.
+func FromName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		parts[i] = strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			case r == '-', r == '_', r == '.':
+				return r
+			default:
+				return '_'
+			}
+		}, part)
+
+		if strings.Trim(parts[i], ".") == "" {
+			parts[i] = strings.Repeat("_", len(parts[i]))
+		}
+	}
+
+	return filepath.Join(parts...)
+}
@@ -0,0 +1,24 @@
+package testpath_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/internal/testpath"
+)
+
+func TestFromName(t *testing.T) {
+	names := map[string]string{
+		"TestFoo":             "TestFoo",
+		"TestFoo/bar_baz":     filepath.Join("TestFoo", "bar_baz"),
+		"TestFoo/a:b*c?#01":   filepath.Join("TestFoo", "a_b_c__01"),
+		"TestFoo/../escape":   filepath.Join("TestFoo", "__", "escape"),
+		"TestFoo/café/GET_/x": filepath.Join("TestFoo", "caf_", "GET_", "x"),
+	}
+
+	for name, expected := range names {
+		if got := testpath.FromName(name); got != expected {
+			t.Errorf("%q: expected %q, got %q", name, expected, got)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+// Package junit converts the output of go test -json, along with sweet's
+// lifecycle events, to a JUnit XML report.
+//
+// A test that failed while its dependencies were being set up is reported as
+// an <error>, rather than a <failure>, so a container that failed to start is
+// told apart from a failed assertion. How long each test's dependencies took
+// to set up and tear down is reported in its properties.
+//
+// The events file can be left over from earlier runs, sweet appends to it;
+// only the events of the runs go test reported on are used.
+package junit
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// The names of the properties reported for tests run with sweet.Run.
+const (
+	SetupProperty   = "sweet.setup.seconds"
+	CleanupProperty = "sweet.cleanup.seconds"
+)
+
+// Suites is a JUnit report, with a suite for each package tested.
+type Suites struct {
+	XMLName xml.Name `xml:"testsuites"`
+	Suites  []*Suite `xml:"testsuite"`
+}
+
+// Suite is the report of a package's tests.
+type Suite struct {
+	Name      string  `xml:"name,attr"`
+	Tests     int     `xml:"tests,attr"`
+	Failures  int     `xml:"failures,attr"`
+	Errors    int     `xml:"errors,attr"`
+	Skipped   int     `xml:"skipped,attr"`
+	Time      seconds `xml:"time,attr"`
+	Timestamp string  `xml:"timestamp,attr,omitempty"`
+	Cases     []*Case `xml:"testcase"`
+}
+
+// Case is the report of a test.
+type Case struct {
+	Name       string      `xml:"name,attr"`
+	Classname  string      `xml:"classname,attr"`
+	Time       seconds     `xml:"time,attr"`
+	Properties *Properties `xml:"properties,omitempty"`
+	Failure    *Problem    `xml:"failure,omitempty"`
+	Error      *Problem    `xml:"error,omitempty"`
+	Skipped    *Problem    `xml:"skipped,omitempty"`
+	SystemOut  *Output     `xml:"system-out,omitempty"`
+
+	output strings.Builder
+	// setupFailed is set when sweet reported the test failing while its
+	// dependencies were set up.
+	setupFailed bool
+}
+
+// Properties are a test's properties.
+type Properties struct {
+	Properties []Property `xml:"property"`
+}
+
+// Property is a named value reported for a test.
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Problem is a test's failure, error or reason for being skipped.
+type Problem struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",cdata"`
+}
+
+// Output is what a test logged.
+type Output struct {
+	Contents string `xml:",cdata"`
+}
+
+type seconds float64
+
+func (s seconds) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{Name: name, Value: fmt.Sprintf("%.3f", float64(s))}, nil
+}
+
+// testEvent is a line of go test -json output.
+type testEvent struct {
+	Time    time.Time
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// Convert reads go test -json output from goTest, and sweet events from
+// events, and returns the JUnit report of them. events may be nil if there
+// are none.
+func Convert(goTest, events io.Reader) (*Suites, error) {
+	r := &report{
+		suites:        map[string]*Suite{},
+		cases:         map[string]*Case{},
+		packageOutput: map[*Suite]*strings.Builder{},
+		packageFailed: map[*Suite]bool{},
+		started:       map[string]time.Time{},
+	}
+
+	if err := decode(goTest, r.addTestEvent); err != nil {
+		return nil, fmt.Errorf("reading go test output: %w", err)
+	}
+	if events != nil {
+		var evs []sweet.Event
+		if err := decode(events, func(ev sweet.Event) { evs = append(evs, ev) }); err != nil {
+			return nil, fmt.Errorf("reading sweet events: %w", err)
+		}
+		r.addSweetEvents(evs)
+	}
+
+	return r.finish(), nil
+}
+
+// Write writes the report as XML to w.
+func (s *Suites) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func decode[E any](r io.Reader, add func(E)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			// go test -json passes on anything that isn't from a test,
+			// like build errors, as is.
+			continue
+		}
+
+		var ev E
+		if err := json.Unmarshal(line, &ev); err != nil {
+			return err
+		}
+		add(ev)
+	}
+	return scanner.Err()
+}
+
+type report struct {
+	order  []*Suite
+	suites map[string]*Suite
+	cases  map[string]*Case
+	// packageOutput holds the output of each package outside of its tests.
+	packageOutput map[*Suite]*strings.Builder
+	// packageFailed holds the packages that failed.
+	packageFailed map[*Suite]bool
+	// started holds when go test started on each package.
+	started map[string]time.Time
+}
+
+func (r *report) suite(name string, at time.Time) *Suite {
+	s, ok := r.suites[name]
+	if !ok {
+		s = &Suite{Name: name}
+		if !at.IsZero() {
+			s.Timestamp = at.UTC().Format(time.RFC3339)
+		}
+		r.suites[name] = s
+		r.order = append(r.order, s)
+	}
+	return s
+}
+
+func (r *report) testCase(pkg, test string) *Case {
+	key := pkg + "\x00" + test
+	c, ok := r.cases[key]
+	if !ok {
+		c = &Case{Name: test, Classname: pkg}
+		r.cases[key] = c
+
+		s := r.suite(pkg, time.Time{})
+		s.Cases = append(s.Cases, c)
+	}
+	return c
+}
+
+func (r *report) addTestEvent(ev testEvent) {
+	s := r.suite(ev.Package, ev.Time)
+	if _, ok := r.started[ev.Package]; !ok {
+		r.started[ev.Package] = ev.Time
+	}
+
+	if ev.Test == "" {
+		switch ev.Action {
+		case "output":
+			if r.packageOutput[s] == nil {
+				r.packageOutput[s] = &strings.Builder{}
+			}
+			r.packageOutput[s].WriteString(ev.Output)
+		case "pass", "fail", "skip":
+			s.Time = seconds(ev.Elapsed)
+			r.packageFailed[s] = ev.Action == "fail"
+		}
+		return
+	}
+
+	c := r.testCase(ev.Package, ev.Test)
+	switch ev.Action {
+	case "output":
+		c.output.WriteString(ev.Output)
+	case "pass":
+		c.Time = seconds(ev.Elapsed)
+	case "fail":
+		c.Time = seconds(ev.Elapsed)
+		c.Failure = &Problem{Message: "failed"}
+	case "skip":
+		c.Time = seconds(ev.Elapsed)
+		c.Skipped = &Problem{Message: "skipped"}
+	}
+}
+
+// addSweetEvents adds the events of the runs go test reported on. The events
+// file is appended to, so it can hold the events of earlier runs of the same
+// tests too: only the runs that started once go test had started on their
+// package count.
+func (r *report) addSweetEvents(events []sweet.Event) {
+	runStarted := map[string]time.Time{}
+	for _, ev := range events {
+		if at, ok := runStarted[ev.Run]; !ok || ev.Time.Before(at) {
+			runStarted[ev.Run] = ev.Time
+		}
+	}
+
+	for _, ev := range events {
+		started, ok := r.started[ev.Package]
+		if !ok || runStarted[ev.Run].Before(started) {
+			continue
+		}
+		r.addSweetEvent(ev)
+	}
+}
+
+func (r *report) addSweetEvent(ev sweet.Event) {
+	// Tests go test didn't report on, e.g. the ones a child process ran,
+	// aren't reported.
+	c, ok := r.cases[ev.Package+"\x00"+ev.Test]
+	if !ok {
+		return
+	}
+
+	switch ev.Action {
+	case sweet.ActionFactoryEnd:
+		c.addProperty(SetupProperty, ev.Elapsed)
+		if ev.Failed {
+			c.setupFailed = true
+		}
+	case sweet.ActionCleanupEnd:
+		c.addProperty(CleanupProperty, ev.Elapsed)
+	}
+}
+
+func (c *Case) addProperty(name string, secs float64) {
+	if c.Properties == nil {
+		c.Properties = &Properties{}
+	}
+
+	p := Property{Name: name, Value: fmt.Sprintf("%.3f", secs)}
+	for i, existing := range c.Properties.Properties {
+		if existing.Name == name {
+			c.Properties.Properties[i] = p
+			return
+		}
+	}
+	c.Properties.Properties = append(c.Properties.Properties, p)
+}
+
+func (c *Case) systemOut() *Output {
+	if c.output.Len() == 0 {
+		return nil
+	}
+	return &Output{Contents: c.output.String()}
+}
+
+// finish counts up the suites, and marks the tests that failed setting up as
+// errors.
+func (r *report) finish() *Suites {
+	for _, s := range r.order {
+		failedTests := false
+
+		for _, c := range s.Cases {
+			s.Tests++
+
+			switch {
+			case c.Failure != nil && c.setupFailed:
+				c.Error = &Problem{Message: "failed setting up dependencies", Contents: c.output.String()}
+				c.Failure = nil
+				s.Errors++
+				failedTests = true
+			case c.Failure != nil:
+				c.Failure.Contents = c.output.String()
+				s.Failures++
+				failedTests = true
+			case c.Skipped != nil:
+				s.Skipped++
+				c.SystemOut = c.systemOut()
+			default:
+				c.SystemOut = c.systemOut()
+			}
+		}
+
+		// A package can fail outside of any test, e.g. setting up package
+		// dependencies in TestMain.
+		if r.packageFailed[s] && !failedTests {
+			output := ""
+			if b := r.packageOutput[s]; b != nil {
+				output = b.String()
+			}
+			s.Cases = append(s.Cases, &Case{
+				Name:      "TestMain",
+				Classname: s.Name,
+				Error:     &Problem{Message: "the package failed outside of its tests", Contents: output},
+			})
+			s.Tests++
+			s.Errors++
+		}
+	}
+
+	return &Suites{Suites: r.order}
+}
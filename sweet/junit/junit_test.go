@@ -0,0 +1,73 @@
+package junit_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/factories/golden"
+	"github.com/barry-hennessy/test/sweet/junit"
+)
+
+func TestConvert(t *testing.T) {
+	convert := func(t *testing.T, g *golden.Golden, withEvents bool) *junit.Suites {
+		t.Helper()
+
+		goTest, err := os.Open("testdata/gotest.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer goTest.Close()
+
+		var suites *junit.Suites
+		if withEvents {
+			events, err := os.Open("testdata/events.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer events.Close()
+
+			suites, err = junit.Convert(goTest, events)
+			if err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			suites, err = junit.Convert(goTest, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		b := &bytes.Buffer{}
+		if err := suites.Write(b); err != nil {
+			t.Fatal(err)
+		}
+		g.Assert(b.Bytes())
+
+		return suites
+	}
+
+	sweet.Run(t, "separates setup errors from failures", golden.New, func(t *testing.T, g *golden.Golden) {
+		suites := convert(t, g, true)
+
+		store := suites.Suites[0]
+		if store.Errors != 1 || store.Failures != 2 || store.Skipped != 1 || store.Tests != 5 {
+			t.Errorf("unexpected counts: %d tests, %d errors, %d failures, %d skipped",
+				store.Tests, store.Errors, store.Failures, store.Skipped)
+		}
+
+		queue := suites.Suites[1]
+		if queue.Errors != 1 || queue.Cases[0].Name != "TestMain" {
+			t.Error("expected the package failing outside of its tests to be reported as an error")
+		}
+	})
+
+	sweet.Run(t, "reports failures without events", golden.New, func(t *testing.T, g *golden.Golden) {
+		suites := convert(t, g, false)
+
+		if store := suites.Suites[0]; store.Errors != 0 || store.Failures != 3 {
+			t.Errorf("expected every failure as a failure, got %d errors and %d failures", store.Errors, store.Failures)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package sweet
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// LimitsEnv is the environment variable setting how many tests can set up
+// each resource class at once, e.g. "docker:4,network:2". It overrides
+// [SetLimit].
+const LimitsEnv = "SWEET_LIMITS"
+
+var (
+	classesMu sync.Mutex
+	classes   = map[string]*resourceClass{}
+)
+
+// resourceClass queues the tests waiting to set up dependencies of a class.
+type resourceClass struct {
+	mu      sync.Mutex
+	limit   int
+	fixed   bool
+	inUse   int
+	holders map[*testing.T]int
+	waiters []waiter
+}
+
+// waiter is a test queued for a slot.
+type waiter struct {
+	t    *testing.T
+	turn chan struct{}
+}
+
+// SetLimit sets how many tests can set up dependencies of class at once. A
+// limit of zero or less means no limit, the default. [LimitsEnv] takes
+// precedence.
+func SetLimit(class string, n int) {
+	c := classFor(class)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fixed {
+		c.limit = n
+		c.grant()
+	}
+}
+
+// Limit wraps factory so that it waits its turn with the other factories of
+// class, e.g. to stop dozens of parallel tests starting containers on the
+// docker daemon at once.
+//
+// At most the class's limit of tests set up dependencies of the class at the
+// same time; a test already setting some up can set up more. A slot is held
+// only while factory runs, not for the whole test.
+//
+// Factories only run in parallel if their tests call [testing.T.Parallel]
+// before calling them, e.g. in a factory wrapping them, rather than in the
+// test body.
+//
+// If the test had to wait, the time it spent queueing is logged separately
+// from the time it spent setting up.
+func Limit[D any](class string, factory DepFactory[D]) DepFactory[D] {
+	return func(t *testing.T) D {
+		t.Helper()
+
+		c := classFor(class)
+
+		queued := time.Now()
+		waited := c.acquire(t)
+		defer c.release(t)
+
+		start := time.Now()
+		defer func() {
+			if waited {
+				t.Logf("sweet: queued %s for a %q slot, set up in %s", start.Sub(queued), class, time.Since(start))
+			}
+		}()
+
+		return factory(t)
+	}
+}
+
+func classFor(name string) *resourceClass {
+	classesMu.Lock()
+	defer classesMu.Unlock()
+
+	if c, ok := classes[name]; ok {
+		return c
+	}
+
+	c := &resourceClass{holders: map[*testing.T]int{}}
+	if limit, ok := envLimit(name); ok {
+		c.limit = limit
+		c.fixed = true
+	}
+	classes[name] = c
+
+	return c
+}
+
+// acquire takes a slot for t, waiting for one if need be. It reports whether
+// it had to wait.
+func (c *resourceClass) acquire(t *testing.T) bool {
+	c.mu.Lock()
+
+	if c.holders[t] > 0 {
+		c.holders[t]++
+		c.mu.Unlock()
+		return false
+	}
+
+	if c.limit <= 0 || c.inUse < c.limit {
+		c.inUse++
+		c.holders[t]++
+		c.mu.Unlock()
+		return false
+	}
+
+	turn := make(chan struct{})
+	c.waiters = append(c.waiters, waiter{t: t, turn: turn})
+	c.mu.Unlock()
+
+	// grant takes the slot on our behalf before waking us.
+	<-turn
+
+	return true
+}
+
+func (c *resourceClass) release(t *testing.T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.holders[t]--
+	if c.holders[t] > 0 {
+		return
+	}
+
+	delete(c.holders, t)
+	c.inUse--
+	c.grant()
+}
+
+// grant hands the free slots to the tests that have waited longest. A test
+// can be queued more than once, e.g. by factories called with [Parallel];
+// once it holds a slot the rest of its queued calls share it.
+func (c *resourceClass) grant() {
+	waiting := c.waiters[:0]
+	for _, w := range c.waiters {
+		switch {
+		case c.holders[w.t] > 0:
+		case c.limit <= 0 || c.inUse < c.limit:
+			c.inUse++
+		default:
+			waiting = append(waiting, w)
+			continue
+		}
+
+		c.holders[w.t]++
+		close(w.turn)
+	}
+	c.waiters = waiting
+}
+
+// envLimit returns the limit [LimitsEnv] sets for class, if any.
+func envLimit(class string) (int, bool) {
+	for _, entry := range strings.Split(os.Getenv(LimitsEnv), ",") {
+		name, value, ok := strings.Cut(entry, ":")
+		if !ok {
+			name, value, ok = strings.Cut(entry, "=")
+		}
+
+		if !ok || strings.TrimSpace(name) != class {
+			continue
+		}
+
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			return n, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,176 @@
+package sweet_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+func TestLimit(t *testing.T) {
+	t.Run("queues factories beyond the limit", func(t *testing.T) {
+		class := t.Name()
+		sweet.SetLimit(class, 2)
+
+		mu := sync.Mutex{}
+		running, most := 0, 0
+
+		factory := sweet.Limit(class, func(t *testing.T) any {
+			mu.Lock()
+			running++
+			if running > most {
+				most = running
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		})
+
+		runConcurrently(t, 6, factory)
+
+		if most != 2 {
+			t.Errorf("expected at most 2 factories running at once, saw %d", most)
+		}
+	})
+
+	t.Run("a test holding a slot can set up more", func(t *testing.T) {
+		class := t.Name()
+		sweet.SetLimit(class, 1)
+
+		inner := sweet.Limit(class, func(t *testing.T) int {
+			return 1
+		})
+		outer := sweet.Limit(class, func(t *testing.T) int {
+			return inner(t) + 1
+		})
+
+		done := make(chan int)
+		go func() {
+			// Not the test goroutine, so a deadlock fails the test
+			// rather than hanging it.
+			sweet.Run(t, "nested", outer, func(t *testing.T, d int) {
+				done <- d
+			})
+		}()
+
+		select {
+		case d := <-done:
+			if d != 2 {
+				t.Errorf("expected 2, got %d", d)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the nested factory deadlocked")
+		}
+	})
+
+	t.Run("a test queueing twice at once takes one slot", func(t *testing.T) {
+		class := t.Name()
+		sweet.SetLimit(class, 1)
+
+		limited := sweet.Limit(class, func(t *testing.T) int {
+			return 1
+		})
+
+		// Hold the only slot, so that both of the factories below queue
+		// for it, then make room for both of them at once.
+		release := make(chan struct{})
+		held := make(chan struct{})
+		go sweet.Limit(class, func(t *testing.T) any {
+			close(held)
+			<-release
+			return nil
+		})(t)
+		<-held
+		time.AfterFunc(20*time.Millisecond, func() {
+			sweet.SetLimit(class, 3)
+			close(release)
+		})
+
+		done := make(chan int)
+		go func() {
+			// Both calls hold the slot at once before giving it back.
+			both := sync.WaitGroup{}
+			both.Add(2)
+			overlapping := sweet.Limit(class, func(t *testing.T) int {
+				both.Done()
+				both.Wait()
+				return 1
+			})
+
+			sweet.Run(t, "twice", func(t *testing.T) int {
+				var a, b int
+				sweet.Parallel(t, sweet.Assign(&a, overlapping), sweet.Assign(&b, overlapping))
+				return a + b
+			}, func(t *testing.T, d int) {})
+
+			// Had the test been given two slots, and given back one, the
+			// only slot would be gone.
+			sweet.SetLimit(class, 1)
+			sweet.Run(t, "after", limited, func(t *testing.T, d int) {
+				done <- d
+			})
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the class lost a slot")
+		}
+	})
+
+	t.Run("the environment overrides the code", func(t *testing.T) {
+		class := t.Name()
+		t.Setenv(sweet.LimitsEnv, "other:3, "+class+"=1")
+		sweet.SetLimit(class, 5)
+
+		mu := sync.Mutex{}
+		running, most := 0, 0
+
+		factory := sweet.Limit(class, func(t *testing.T) any {
+			mu.Lock()
+			running++
+			if running > most {
+				most = running
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		})
+
+		runConcurrently(t, 3, factory)
+
+		if most != 1 {
+			t.Errorf("expected at most 1 factory running at once, saw %d", most)
+		}
+	})
+}
+
+// runConcurrently runs n tests using factory at the same time. Unlike with
+// t.Parallel, how many run at once doesn't depend on -test.parallel.
+func runConcurrently(t *testing.T, n int, factory sweet.DepFactory[any]) {
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		i := i
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sweet.Run(t, fmt.Sprintf("test %d", i), factory, func(t *testing.T, d any) {})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,221 @@
+package sweet
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"testing"
+)
+
+// Package is the scope of the dependencies set up by [Main]: the whole test
+// binary.
+type Package struct {
+	mu       sync.Mutex
+	cleanups []func()
+	once     sync.Once
+}
+
+// PackageSetup is a dependency for [Main] to set up. See [NewPackageDep].
+type PackageSetup interface {
+	setup(p *Package) error
+	String() string
+}
+
+// PackageDep is a dependency created once per test binary by [Main], and
+// shared by every test in it. Use it for things that are too expensive to
+// create per test, like a docker network or a database server, and have
+// [DepFactory] implementations build fresh per test state on top of it, e.g.
+// a new database on the shared server.
+type PackageDep[D any] struct {
+	name   string
+	create func(p *Package) (D, error)
+
+	mu    sync.RWMutex
+	value D
+	ready bool
+}
+
+var (
+	currentMu      sync.Mutex
+	currentPackage *Package
+
+	// packageValues holds the values of every [PackageDep] that is set up, so
+	// [Run] doesn't close them after the first test that uses them.
+	packageValues sync.Map
+)
+
+// Main sets up the package scoped deps, runs the tests and tears the deps down
+// again. Call it from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(sweet.Main(m, network, postgres))
+//	}
+//
+// Deps are set up in order and torn down in reverse. If one can't be set up no
+// tests are run.
+//
+// The deps are torn down even if a test run with [Run] panics, or the test
+// binary is interrupted.
+func Main(m *testing.M, deps ...PackageSetup) int {
+	p := &Package{}
+
+	currentMu.Lock()
+	currentPackage = p
+	currentMu.Unlock()
+
+	defer func() {
+		p.teardown()
+
+		currentMu.Lock()
+		currentPackage = nil
+		currentMu.Unlock()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(signals)
+		close(signals)
+	}()
+
+	go func() {
+		sig, ok := <-signals
+		if !ok {
+			return
+		}
+
+		p.Logf("sweet: %s received, tearing down package dependencies", sig)
+		p.teardown()
+		os.Exit(130)
+	}()
+
+	for _, d := range deps {
+		if err := d.setup(p); err != nil {
+			p.Logf("sweet: setting up %s: %s", d, err)
+			return 1
+		}
+	}
+
+	return m.Run()
+}
+
+// Cleanup registers f to be called when the test binary is done. Like
+// [testing.T.Cleanup] functions are called in reverse order.
+func (p *Package) Cleanup(f func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cleanups = append(p.cleanups, f)
+}
+
+// Logf writes to the test binary's standard error, there being no test to log
+// to.
+func (p *Package) Logf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+// teardown calls the cleanups. Only the first call does anything.
+func (p *Package) teardown() {
+	p.once.Do(func() {
+		p.mu.Lock()
+		cleanups := p.cleanups
+		p.mu.Unlock()
+
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	})
+}
+
+// teardownOnPanic tears down the package deps if the test is panicking, before
+// passing the panic on. It is a no-op if [Main] isn't being used, so panics
+// aren't recovered needlessly.
+func teardownOnPanic() {
+	currentMu.Lock()
+	p := currentPackage
+	currentMu.Unlock()
+
+	if p == nil {
+		return
+	}
+
+	if r := recover(); r != nil {
+		p.Logf("sweet: test panicked, tearing down package dependencies")
+		p.teardown()
+		panic(r)
+	}
+}
+
+// NewPackageDep creates a dependency for [Main] to set up by calling create.
+// create registers any cleanup needed with [Package.Cleanup].
+func NewPackageDep[D any](name string, create func(p *Package) (D, error)) *PackageDep[D] {
+	return &PackageDep[D]{
+		name:   name,
+		create: create,
+	}
+}
+
+// Get returns the dependency. It fails the test if [Main] didn't set it up.
+//
+// Get is a [DepFactory]; the same value is returned to every test.
+func (d *PackageDep[D]) Get(t *testing.T) D {
+	t.Helper()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if !d.ready {
+		t.Fatalf("sweet: package dependency %s is not set up; pass it to sweet.Main in TestMain", d)
+	}
+
+	return d.value
+}
+
+func (d *PackageDep[D]) String() string {
+	return d.name
+}
+
+func (d *PackageDep[D]) setup(p *Package) error {
+	value, err := d.create(p)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.value = value
+	d.ready = true
+	d.mu.Unlock()
+
+	key, isRef := valueKey(value)
+	if isRef {
+		packageValues.Store(key, true)
+	}
+
+	p.Cleanup(func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		var zero D
+		d.value = zero
+		d.ready = false
+
+		if isRef {
+			packageValues.Delete(key)
+		}
+	})
+
+	return nil
+}
+
+// isPackageValue reports whether v is the value of a [PackageDep].
+func isPackageValue(v any) bool {
+	key, isRef := valueKey(v)
+	if !isRef {
+		return false
+	}
+
+	_, ok := packageValues.Load(key)
+	return ok
+}
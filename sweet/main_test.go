@@ -0,0 +1,92 @@
+package sweet_test
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type server struct {
+	running bool
+	closed  bool
+}
+
+func (s *server) Close() error {
+	s.closed = true
+	return nil
+}
+
+// serverTornDown is set by the shared server's cleanup, for TestMain to check
+// once Main returns.
+var serverTornDown bool
+
+var sharedServer = sweet.NewPackageDep("server", func(p *sweet.Package) (*server, error) {
+	s := &server{running: true}
+
+	p.Cleanup(func() {
+		s.running = false
+		serverTornDown = true
+
+		// Only the output of runFailing's child is checked for it; it's noise
+		// in any other run.
+		if os.Getenv(failingEnv) != "" {
+			p.Logf("server torn down")
+		}
+	})
+
+	return s, nil
+})
+
+func TestMain(m *testing.M) {
+	code := sweet.Main(m, sharedServer)
+
+	if !serverTornDown {
+		fmt.Fprintln(os.Stderr, "sweet_test: the package dependencies were not torn down")
+		code = 1
+	}
+
+	os.Exit(code)
+}
+
+func TestMain_packageDeps(t *testing.T) {
+	var first, second *server
+
+	sweet.Run(t, "first", sharedServer.Get, func(t *testing.T, s *server) {
+		first = s
+	})
+
+	sweet.Run(t, "second", sharedServer.Get, func(t *testing.T, s *server) {
+		second = s
+	})
+
+	if first != second {
+		t.Error("the tests got different servers")
+	}
+
+	if !first.running {
+		t.Error("the server is not running")
+	}
+
+	if first.closed {
+		t.Error("the shared server was closed after a test")
+	}
+
+	t.Run("are torn down when a test panics", func(t *testing.T) {
+		out := runFailing(t, "TestMain_panicking")
+
+		if !regexp.MustCompile(`(?s)tearing down package dependencies.*server torn down.*panicked on purpose`).MatchString(out) {
+			t.Errorf("the package deps were not torn down:\n%s", out)
+		}
+	})
+}
+
+func TestMain_panicking(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweet.Run(t, "panics", sharedServer.Get, func(t *testing.T, s *server) {
+		panic("panicked on purpose")
+	})
+}
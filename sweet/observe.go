@@ -0,0 +1,155 @@
+package sweet
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Observer watches the steps in the life of tests run with [Run], e.g. to
+// trace them. See [Event] for the steps.
+type Observer interface {
+	// StartStep is called as a step starts, with the test's [Context]. The
+	// context it returns, e.g. one carrying a span for the step, is the
+	// test's Context until the step ends.
+	//
+	// The Context of a test run with [Run] inherits the values of the
+	// Context of the test it is nested in, so steps can be nested across
+	// tests too.
+	StartStep(ctx context.Context, ev Event) context.Context
+	// EndStep is called as the step ends, with the context StartStep
+	// returned.
+	EndStep(ctx context.Context, ev Event)
+}
+
+var observers struct {
+	mu   sync.RWMutex
+	list []*Observer
+}
+
+// Observe tells o of the steps of every test run with [Run], until the
+// returned function is called.
+func Observe(o Observer) (stop func()) {
+	observers.mu.Lock()
+	defer observers.mu.Unlock()
+
+	// Observers are kept by pointer, so the same one can be added twice and
+	// stopped separately.
+	p := &o
+	observers.list = append(observers.list, p)
+
+	return func() {
+		observers.mu.Lock()
+		defer observers.mu.Unlock()
+
+		for i, existing := range observers.list {
+			if existing == p {
+				observers.list = append(observers.list[:i:i], observers.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func observing() bool {
+	observers.mu.RLock()
+	defer observers.mu.RUnlock()
+
+	return len(observers.list) > 0
+}
+
+func currentObservers() []Observer {
+	observers.mu.RLock()
+	defer observers.mu.RUnlock()
+
+	list := make([]Observer, 0, len(observers.list))
+	for _, o := range observers.list {
+		list = append(list, *o)
+	}
+	return list
+}
+
+// step is a step in the life of a test, written as events and told to the
+// observers.
+type step struct {
+	t         *testing.T
+	start     time.Time
+	observers []Observer
+	// ctx is the context the observers returned.
+	ctx     context.Context
+	restore func()
+}
+
+// startStep starts a step of t. Observers start it from base or, if base is
+// nil, from t's Context, which the context they return replaces until the
+// step ends.
+func startStep(t *testing.T, action string, base context.Context) *step {
+	ev := newEvent(t, action, time.Time{})
+	writeEvent(ev)
+
+	s := &step{t: t, start: ev.Time, observers: currentObservers()}
+	if len(s.observers) == 0 {
+		return s
+	}
+
+	ctx := base
+	if ctx == nil {
+		ctx = Context(t)
+	}
+	for _, o := range s.observers {
+		ctx = o.StartStep(ctx, ev)
+	}
+	s.ctx = ctx
+
+	if base == nil {
+		s.restore = setContext(t, ctx)
+	}
+
+	return s
+}
+
+// end ends the step.
+func (s *step) end(action string) {
+	if s == nil {
+		return
+	}
+
+	ev := newEvent(s.t, action, s.start)
+	writeEvent(ev)
+
+	if s.restore != nil {
+		s.restore()
+	}
+	for i := len(s.observers) - 1; i >= 0; i-- {
+		s.observers[i].EndStep(s.ctx, ev)
+	}
+}
+
+// inheritValues makes t's Context inherit the values of parent's, if parent
+// has one.
+func inheritValues(t, parent *testing.T) {
+	v, ok := contexts.Load(parent)
+	if !ok {
+		return
+	}
+	tc := v.(*testContext)
+	tc.mu.Lock()
+	values := tc.ctx
+	tc.mu.Unlock()
+
+	setContext(t, inheritedContext{Context: Context(t), values: values})
+}
+
+// inheritedContext is a context with the values of another.
+type inheritedContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c inheritedContext) Value(key any) any {
+	if v := c.Context.Value(key); v != nil {
+		return v
+	}
+	return c.values.Value(key)
+}
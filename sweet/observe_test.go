@@ -0,0 +1,88 @@
+package sweet_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type stepKey struct{}
+
+// nestingObserver records each step along with the steps it was started in,
+// like a tracer recording spans and their parents.
+type nestingObserver struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (o *nestingObserver) StartStep(ctx context.Context, ev sweet.Event) context.Context {
+	path := strings.TrimSuffix(ev.Action, "-start")
+	if parent, ok := ctx.Value(stepKey{}).(string); ok {
+		path = parent + " > " + path
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.steps = append(o.steps, path)
+
+	return context.WithValue(ctx, stepKey{}, path)
+}
+
+func (o *nestingObserver) EndStep(ctx context.Context, ev sweet.Event) {}
+
+func currentStep(t *testing.T) string {
+	step, _ := sweet.Context(t).Value(stepKey{}).(string)
+	return step
+}
+
+func TestObserve(t *testing.T) {
+	o := &nestingObserver{}
+	stop := sweet.Observe(o)
+	defer stop()
+
+	var inFactory, inBody, inNestedBody string
+	factory := func(t *testing.T) int {
+		inFactory = currentStep(t)
+		return 1
+	}
+
+	sweet.Run(t, "outer", factory, func(t *testing.T, d int) {
+		inBody = currentStep(t)
+
+		sweet.Run(t, "inner", nil, func(t *testing.T, d any) {
+			inNestedBody = currentStep(t)
+		})
+	})
+
+	if want := "test > factory"; inFactory != want {
+		t.Errorf("got step %q in the factory, want %q", inFactory, want)
+	}
+	if want := "test > body"; inBody != want {
+		t.Errorf("got step %q in the test body, want %q", inBody, want)
+	}
+	if want := "test > body > test > body"; inNestedBody != want {
+		t.Errorf("got step %q in the nested test body, want %q", inNestedBody, want)
+	}
+
+	want := []string{
+		"test",
+		"test > factory",
+		"test > body",
+		"test > body > test",
+		"test > body > test > body",
+		"test > body > test > cleanup",
+		"test > cleanup",
+	}
+	if strings.Join(o.steps, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got steps:\n%s\nwant:\n%s", strings.Join(o.steps, "\n"), strings.Join(want, "\n"))
+	}
+
+	stop()
+	sweet.Run(t, "stopped", nil, func(t *testing.T, d any) {})
+	if len(o.steps) != len(want) {
+		t.Errorf("observed steps after being stopped: %v", o.steps[len(want):])
+	}
+}
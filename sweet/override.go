@@ -0,0 +1,113 @@
+package sweet
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Override creates a factory that calls factory and then tweak, on what it
+// created, before handing it to the test. Use it for the one test that needs
+// the standard dependencies with a small change:
+//
+//	sweet.Run(t, "with the feature on", sweet.Override(serviceFactory,
+//		func(t *testing.T, d *serviceDeps) {
+//			d.Flags.Enable("new-checkout")
+//		}),
+//		func(t *testing.T, d serviceDeps) {...},
+//	)
+//
+// Anything tweak creates is cleaned up with t, as usual.
+func Override[D any](factory DepFactory[D], tweak func(t *testing.T, d *D)) DepFactory[D] {
+	return func(t *testing.T) D {
+		d := factory(t)
+		tweak(t, &d)
+		return d
+	}
+}
+
+// Slot is a factory, nested inside other factories, that can be replaced for
+// a part of the test tree. Where [Override] changes what a factory returns,
+// a slot changes how one of its parts is built; e.g. a different database
+// image for a service that is otherwise built the standard way:
+//
+//	var database = sweet.NewSlot(postgresFactory)
+//
+//	func serviceFactory(t *testing.T) *Service {
+//		return NewService(database.Get(t))
+//	}
+//
+//	func TestService(t *testing.T) {
+//		t.Run("on postgres 12", func(t *testing.T) {
+//			database.Replace(t, postgresFactoryFor("postgres:12"))
+//
+//			sweet.Run(t, "saves", serviceFactory, ...)
+//		})
+//	}
+type Slot[D any] struct {
+	factory DepFactory[D]
+
+	mu sync.Mutex
+	// replacements holds the factories replacing the default, by the name
+	// of the test whose subtree they apply to. The last one wins.
+	replacements map[string][]DepFactory[D]
+}
+
+// NewSlot creates a slot that builds its dependency with factory unless it
+// has been replaced.
+func NewSlot[D any](factory DepFactory[D]) *Slot[D] {
+	return &Slot[D]{
+		factory:      factory,
+		replacements: map[string][]DepFactory[D]{},
+	}
+}
+
+// Get builds the slot's dependency for t. It uses the factory that replaced
+// the slot for the closest of t and its parents, or the slot's own factory if
+// none did. Get is a [DepFactory] itself.
+func (s *Slot[D]) Get(t *testing.T) D {
+	t.Helper()
+
+	return s.factoryFor(t.Name())(t)
+}
+
+// Replace makes the slot build its dependency with factory, for t and all of
+// its subtests, until t ends.
+func (s *Slot[D]) Replace(t *testing.T, factory DepFactory[D]) {
+	name := t.Name()
+
+	s.mu.Lock()
+	s.replacements[name] = append(s.replacements[name], factory)
+	s.mu.Unlock()
+
+	t.Cleanup(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		replacements := s.replacements[name]
+		if len(replacements) <= 1 {
+			delete(s.replacements, name)
+			return
+		}
+		s.replacements[name] = replacements[:len(replacements)-1]
+	})
+}
+
+// factoryFor returns the factory to use for the test named name.
+func (s *Slot[D]) factoryFor(name string) DepFactory[D] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Walk up from the test itself to the top level test.
+	for {
+		if replacements := s.replacements[name]; len(replacements) > 0 {
+			return replacements[len(replacements)-1]
+		}
+
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			return s.factory
+		}
+		name = name[:i]
+	}
+}
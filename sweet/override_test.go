@@ -0,0 +1,79 @@
+package sweet_test
+
+import (
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type service struct {
+	db    string
+	flags []string
+}
+
+func TestOverride(t *testing.T) {
+	factory := func(t *testing.T) service {
+		return service{db: "postgres"}
+	}
+
+	sweet.Run(t, "tweaks what the factory made", sweet.Override(factory,
+		func(t *testing.T, d *service) {
+			d.flags = append(d.flags, "beta")
+		}),
+		func(t *testing.T, d service) {
+			if d.db != "postgres" {
+				t.Errorf("got db %q, want the factory's %q", d.db, "postgres")
+			}
+			if len(d.flags) != 1 || d.flags[0] != "beta" {
+				t.Errorf("got flags %v, want [beta]", d.flags)
+			}
+		})
+
+	sweet.Run(t, "leaves the factory alone", factory, func(t *testing.T, d service) {
+		if len(d.flags) != 0 {
+			t.Errorf("got flags %v, want none", d.flags)
+		}
+	})
+}
+
+func TestSlot(t *testing.T) {
+	database := sweet.NewSlot(func(t *testing.T) string {
+		return "postgres"
+	})
+	serviceFactory := func(t *testing.T) service {
+		return service{db: database.Get(t)}
+	}
+	expectDB := func(want string) func(t *testing.T, d service) {
+		return func(t *testing.T, d service) {
+			if d.db != want {
+				t.Errorf("got db %q, want %q", d.db, want)
+			}
+		}
+	}
+
+	sweet.Run(t, "builds with the default", serviceFactory, expectDB("postgres"))
+
+	t.Run("replaced", func(t *testing.T) {
+		database.Replace(t, func(t *testing.T) string {
+			return "sqlite"
+		})
+
+		sweet.Run(t, "builds with the replacement", serviceFactory, expectDB("sqlite"))
+
+		t.Run("again", func(t *testing.T) {
+			database.Replace(t, func(t *testing.T) string {
+				return "mysql"
+			})
+
+			sweet.Run(t, "the closest replacement wins", serviceFactory, expectDB("mysql"))
+		})
+
+		sweet.Run(t, "until the subtree ends", serviceFactory, expectDB("sqlite"))
+	})
+
+	t.Run("replaced_sibling", func(t *testing.T) {
+		sweet.Run(t, "is not affected", serviceFactory, expectDB("postgres"))
+	})
+
+	sweet.Run(t, "builds with the default after", serviceFactory, expectDB("postgres"))
+}
@@ -0,0 +1,99 @@
+package sweet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Parallel calls factories concurrently, on t, and waits for them all to
+// return. Use it to build a struct of dependencies that are slow to start,
+// like containers, in the time of the slowest rather than the sum of them
+// all:
+//
+//	func stackFactory(t *testing.T) stack {
+//		s := stack{}
+//		sweet.Parallel(t,
+//			sweet.Assign(&s.Postgres, postgresFactory),
+//			sweet.Assign(&s.Redis, redisFactory),
+//		)
+//		return s
+//	}
+//
+// Cleanups the factories register run when t ends, as usual. Each factory's
+// cleanups run in reverse order; the order between factories is not defined.
+//
+// The factories run on goroutines of their own, so, as with any goroutine
+// other than the test's, they must not call [testing.T.FailNow],
+// [testing.T.Fatal] and the like. They report failures with
+// [testing.T.Error] and return instead.
+//
+// If a factory fails the test, or panics, the [Context] the others see is
+// cancelled so they can give up early. Once they have all returned Parallel
+// passes the failure on; stopping the test with [testing.T.FailNow], or
+// panicking, on t's goroutine.
+func Parallel(t *testing.T, factories ...func(t *testing.T)) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(Context(t))
+	defer cancel()
+	defer setContext(t, ctx)()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failed   = -1
+		panicked any
+	)
+
+	// Only failures from here on are the factories'.
+	failedBefore := t.Failed()
+
+	for i, factory := range factories {
+		i, factory := i, factory
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			returned := false
+			defer func() {
+				r := recover()
+				if returned && r == nil && (failedBefore || !t.Failed()) {
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if failed < 0 {
+					failed = i
+					panicked = r
+				}
+				cancel()
+			}()
+
+			factory(t)
+			returned = true
+		}()
+	}
+
+	wg.Wait()
+
+	if panicked != nil {
+		panic(fmt.Sprintf("sweet: parallel factory %d panicked: %v", failed, panicked))
+	}
+
+	if failed >= 0 {
+		t.Logf("sweet: parallel factory %d failed the test", failed)
+		t.FailNow()
+	}
+}
+
+// Assign creates a function for [Parallel] that calls factory and stores the
+// dependency it creates in dst.
+func Assign[D any](dst *D, factory DepFactory[D]) func(t *testing.T) {
+	return func(t *testing.T) {
+		*dst = factory(t)
+	}
+}
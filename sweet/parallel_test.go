@@ -0,0 +1,110 @@
+package sweet_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type slowDep struct {
+	name      string
+	cleanedUp bool
+}
+
+func TestParallel(t *testing.T) {
+	t.Run("runs the factories at the same time", func(t *testing.T) {
+		a, b := make(chan struct{}), make(chan struct{})
+
+		// Each factory waits for the other to start, so they only both
+		// return if they run at the same time.
+		waitFor := func(t *testing.T, started, other chan struct{}) {
+			close(started)
+			select {
+			case <-other:
+			case <-time.After(5 * time.Second):
+				t.Error("the factories did not run at the same time")
+			}
+		}
+
+		sweet.Parallel(t,
+			func(t *testing.T) { waitFor(t, a, b) },
+			func(t *testing.T) { waitFor(t, b, a) },
+		)
+	})
+
+	t.Run("assigns the dependencies and cleans them up", func(t *testing.T) {
+		type stack struct {
+			A, B *slowDep
+		}
+
+		slowFactory := func(name string) sweet.DepFactory[*slowDep] {
+			return func(t *testing.T) *slowDep {
+				d := &slowDep{name: name}
+				t.Cleanup(func() {
+					d.cleanedUp = true
+				})
+				return d
+			}
+		}
+
+		var s stack
+		sweet.Run(t, "stack", func(t *testing.T) stack {
+			s := stack{}
+			sweet.Parallel(t,
+				sweet.Assign(&s.A, slowFactory("a")),
+				sweet.Assign(&s.B, slowFactory("b")),
+			)
+			return s
+		}, func(t *testing.T, d stack) {
+			s = d
+
+			if d.A.name != "a" || d.B.name != "b" {
+				t.Errorf("the dependencies were not assigned: %+v, %+v", d.A, d.B)
+			}
+		})
+
+		if !s.A.cleanedUp || !s.B.cleanedUp {
+			t.Error("the dependencies were not cleaned up")
+		}
+	})
+
+	t.Run("a failure cancels the others and stops the test", func(t *testing.T) {
+		out := runFailing(t, "TestParallel_failing")
+
+		for _, expected := range []string{"could not start", "the other factory was cancelled", "parallel factory 0 failed the test"} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected %q in the output:\n%s", expected, out)
+			}
+		}
+
+		if strings.Contains(out, "the test body ran") {
+			t.Errorf("the test body ran after a factory failed:\n%s", out)
+		}
+	})
+}
+
+func TestParallel_failing(t *testing.T) {
+	skipUnlessFailing(t)
+
+	factory := func(t *testing.T) any {
+		sweet.Parallel(t,
+			func(t *testing.T) {
+				t.Error("could not start")
+			},
+			func(t *testing.T) {
+				select {
+				case <-sweet.Context(t).Done():
+					t.Log("the other factory was cancelled")
+				case <-time.After(5 * time.Second):
+				}
+			},
+		)
+		return nil
+	}
+
+	sweet.Run(t, "fails", factory, func(t *testing.T, d any) {
+		t.Log("the test body ran")
+	})
+}
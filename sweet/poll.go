@@ -0,0 +1,149 @@
+package sweet
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// The defaults of [PollOptions].
+const (
+	defaultEventuallyTimeout   = 5 * time.Second
+	defaultConsistentlyTimeout = 500 * time.Millisecond
+	defaultInterval            = 10 * time.Millisecond
+	defaultMaxInterval         = time.Second
+)
+
+// PollOptions configure how [Eventually] and [Consistently] poll. The zero
+// value uses the defaults.
+type PollOptions struct {
+	// Timeout is how long Eventually waits for the condition to hold, 5s by
+	// default, and how long Consistently checks it holds for, 500ms by
+	// default. Either way polling stops at the test's deadline.
+	Timeout time.Duration
+
+	// Interval is the time between checks, 10ms by default. Eventually
+	// doubles it after each check, up to MaxInterval.
+	Interval time.Duration
+
+	// MaxInterval caps the time between the checks of Eventually, 1s by
+	// default.
+	MaxInterval time.Duration
+}
+
+func (o PollOptions) withDefaults(timeout time.Duration) PollOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = timeout
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = defaultMaxInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	return o
+}
+
+// Eventually checks cond until it holds, i.e. returns a nil error, and
+// returns the value it observed then. It's for dependencies that get there in
+// their own time, like a message delivered by NATS or a document showing up
+// on a change stream.
+//
+// cond is passed t's [Context], bounded by the timeout, to make its calls
+// with. Checks back off, from the interval to the max interval.
+//
+// If cond doesn't hold by the timeout, or the test's deadline, the test fails
+// with the last value cond observed and the error it returned, the [OnFailure]
+// hooks of t are run while the dependencies are still in the state that
+// failed it, and Eventually calls t.FailNow.
+func Eventually[T any](t *testing.T, cond func(ctx context.Context) (T, error), opts PollOptions) T {
+	t.Helper()
+
+	opts = opts.withDefaults(defaultEventuallyTimeout)
+	ctx, cancel := context.WithTimeout(Context(t), opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	interval := opts.Interval
+	for checks := 1; ; checks++ {
+		v, err := cond(ctx)
+		if err == nil {
+			return v
+		}
+
+		if !wait(ctx, interval) {
+			pollFailed(t, fmt.Sprintf("sweet: condition did not hold within %s, after %d check(s)", since(start), checks), v, err)
+			return v
+		}
+
+		interval *= 2
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+// Consistently checks cond, every interval until the timeout, and returns the
+// value it last observed. It's for checking something doesn't happen, like a
+// message that mustn't be delivered, when there is no event to wait for.
+//
+// cond is passed t's [Context], bounded by the timeout, to make its calls
+// with.
+//
+// If cond stops holding, i.e. returns an error, the test fails with the value
+// cond observed and the error, the [OnFailure] hooks of t are run while the
+// dependencies are still in the state that failed it, and Consistently calls
+// t.FailNow.
+func Consistently[T any](t *testing.T, cond func(ctx context.Context) (T, error), opts PollOptions) T {
+	t.Helper()
+
+	opts = opts.withDefaults(defaultConsistentlyTimeout)
+	ctx, cancel := context.WithTimeout(Context(t), opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	for checks := 1; ; checks++ {
+		v, err := cond(ctx)
+		// An error caused by the timeout cutting a check short doesn't
+		// count: cond held for as long as it had to.
+		if err != nil && ctx.Err() == nil {
+			pollFailed(t, fmt.Sprintf("sweet: condition stopped holding after %s, on check %d", since(start), checks), v, err)
+			return v
+		}
+
+		if !wait(ctx, opts.Interval) {
+			return v
+		}
+	}
+}
+
+// wait waits for d to pass, reporting false if ctx is done first.
+func wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func since(start time.Time) time.Duration {
+	return time.Since(start).Round(time.Millisecond)
+}
+
+// pollFailed fails t with what cond last observed, then runs its failure
+// hooks and stops it.
+func pollFailed(t *testing.T, msg string, v any, err error) {
+	t.Helper()
+
+	t.Errorf("%s\nlast value: %#v\nlast error: %v", msg, v, err)
+	runDiagnostics(t)
+	t.FailNow()
+}
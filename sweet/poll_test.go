@@ -0,0 +1,145 @@
+package sweet_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// queue stands in for an asynchronous dependency: messages published to it
+// are delivered a little later.
+type queue struct {
+	delivered int64
+}
+
+func (q *queue) publish(after time.Duration) {
+	go func() {
+		time.Sleep(after)
+		atomic.AddInt64(&q.delivered, 1)
+	}()
+}
+
+func (q *queue) count(ctx context.Context) (int64, error) {
+	n := atomic.LoadInt64(&q.delivered)
+	if n == 0 {
+		return n, errors.New("nothing delivered")
+	}
+	return n, nil
+}
+
+func newQueue(t *testing.T) *queue {
+	q := &queue{}
+	sweet.OnFailure(t, func(t *testing.T) {
+		t.Logf("queue: %d message(s) delivered", atomic.LoadInt64(&q.delivered))
+	})
+	return q
+}
+
+func TestEventually(t *testing.T) {
+	sweet.Run(t, "delivered", newQueue, func(t *testing.T, q *queue) {
+		q.publish(30 * time.Millisecond)
+
+		checks := 0
+		n := sweet.Eventually(t, func(ctx context.Context) (int64, error) {
+			checks++
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("expected the context to have the timeout as its deadline")
+			}
+			return q.count(ctx)
+		}, sweet.PollOptions{Interval: time.Millisecond, MaxInterval: 8 * time.Millisecond})
+
+		if n != 1 {
+			t.Errorf("expected the count once delivered, got %d", n)
+		}
+		// Backing off from 1ms to 8ms, 30ms takes about 7 checks rather than
+		// 30.
+		if checks < 2 || checks > 15 {
+			t.Errorf("expected the checks to back off, got %d in 30ms", checks)
+		}
+	})
+}
+
+func TestEventually_timeout(t *testing.T) {
+	out := runFailing(t, "TestEventually_timeoutFails")
+
+	for _, want := range []string{
+		"sweet: condition did not hold within",
+		"last value: 0\n",
+		"last error: nothing delivered\n",
+		"sweet: dependency diagnostics",
+		"queue: 0 message(s) delivered",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "after Eventually") {
+		t.Errorf("expected Eventually to stop the test, got:\n%s", out)
+	}
+	if strings.Count(out, "sweet: dependency diagnostics") != 1 {
+		t.Errorf("expected the diagnostics to run once, got:\n%s", out)
+	}
+}
+
+func TestEventually_timeoutFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweet.Run(t, "never delivered", newQueue, func(t *testing.T, q *queue) {
+		sweet.Eventually(t, q.count, sweet.PollOptions{Timeout: 50 * time.Millisecond})
+		t.Log("after Eventually")
+	})
+}
+
+func TestConsistently(t *testing.T) {
+	sweet.Run(t, "nothing delivered", newQueue, func(t *testing.T, q *queue) {
+		checks := 0
+		sweet.Consistently(t, func(ctx context.Context) (int64, error) {
+			checks++
+			if n, _ := q.count(ctx); n != 0 {
+				return n, fmt.Errorf("%d delivered", n)
+			}
+			return 0, nil
+		}, sweet.PollOptions{Timeout: 50 * time.Millisecond, Interval: 5 * time.Millisecond})
+
+		if checks < 2 {
+			t.Errorf("expected the condition to be checked until the timeout, got %d check(s)", checks)
+		}
+	})
+}
+
+func TestConsistently_fails(t *testing.T) {
+	out := runFailing(t, "TestConsistently_failsFails")
+
+	for _, want := range []string{
+		"sweet: condition stopped holding after",
+		"last value: 1\n",
+		"last error: 1 delivered\n",
+		"queue: 1 message(s) delivered",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected the output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestConsistently_failsFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweet.Run(t, "delivered", newQueue, func(t *testing.T, q *queue) {
+		q.publish(10 * time.Millisecond)
+
+		sweet.Consistently(t, func(ctx context.Context) (int64, error) {
+			if n, _ := q.count(ctx); n != 0 {
+				return n, fmt.Errorf("%d delivered", n)
+			}
+			return 0, nil
+		}, sweet.PollOptions{Timeout: time.Second, Interval: 5 * time.Millisecond})
+	})
+}
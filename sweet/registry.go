@@ -0,0 +1,50 @@
+package sweet
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// registry holds the slot [Provide] registered for each type.
+var registry sync.Map
+
+// Provide registers factory as the way to build a D for [Resolve], and so for
+// factories generated by sweetgen. Providing a second factory for D replaces
+// the first.
+//
+// Provide a package's factories once, e.g. in a var block of a test file:
+//
+//	var _ = sweet.Provide(postgresFactory)
+//
+// The returned slot can replace the factory for a part of the test tree, as
+// with any other [Slot].
+func Provide[D any](factory DepFactory[D]) *Slot[D] {
+	v, loaded := registry.LoadOrStore(typeOf[D](), NewSlot(factory))
+	s := v.(*Slot[D])
+
+	if loaded {
+		s.mu.Lock()
+		s.factory = factory
+		s.mu.Unlock()
+	}
+
+	return s
+}
+
+// Resolve builds a D for t, with the factory [Provide] registered for D. It
+// fails the test if none was.
+func Resolve[D any](t *testing.T) D {
+	t.Helper()
+
+	v, ok := registry.Load(typeOf[D]())
+	if !ok {
+		t.Fatalf("sweet: no factory provided for %s", typeOf[D]())
+	}
+
+	return v.(*Slot[D]).Get(t)
+}
+
+func typeOf[D any]() reflect.Type {
+	return reflect.TypeOf((*D)(nil)).Elem()
+}
@@ -0,0 +1,59 @@
+package sweet_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type (
+	registeredDB   struct{ name string }
+	unregisteredDB struct{}
+)
+
+func TestProvide(t *testing.T) {
+	db := sweet.Provide(func(t *testing.T) *registeredDB {
+		return &registeredDB{name: "postgres"}
+	})
+
+	resolve := func(t *testing.T) *registeredDB {
+		return sweet.Resolve[*registeredDB](t)
+	}
+	expectDB := func(want string) func(t *testing.T, d *registeredDB) {
+		return func(t *testing.T, d *registeredDB) {
+			if d.name != want {
+				t.Errorf("got db %q, want %q", d.name, want)
+			}
+		}
+	}
+
+	sweet.Run(t, "resolves the provided factory", resolve, expectDB("postgres"))
+
+	t.Run("replaced", func(t *testing.T) {
+		db.Replace(t, func(t *testing.T) *registeredDB {
+			return &registeredDB{name: "sqlite"}
+		})
+
+		sweet.Run(t, "resolves the replacement", resolve, expectDB("sqlite"))
+	})
+
+	sweet.Provide(func(t *testing.T) *registeredDB {
+		return &registeredDB{name: "mysql"}
+	})
+	sweet.Run(t, "resolves the last factory provided", resolve, expectDB("mysql"))
+}
+
+func TestResolve_unprovided(t *testing.T) {
+	out := runFailing(t, "TestResolve_unprovidedFails")
+
+	if !strings.Contains(out, "sweet: no factory provided for *sweet_test.unregisteredDB") {
+		t.Errorf("expected the missing type to be reported, got:\n%s", out)
+	}
+}
+
+func TestResolve_unprovidedFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweet.Resolve[*unregisteredDB](t)
+}
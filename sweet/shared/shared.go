@@ -0,0 +1,339 @@
+// Package shared shares dependencies between test binaries.
+//
+// `go test ./...` runs each package's tests in a process of its own, so every
+// package starts its own containers. With shared, the first test binary to ask
+// for a named dependency starts it and the others use that same instance. It
+// is stopped once the last of them is done with it, and no other binary has
+// asked for it within an idle timeout.
+//
+// The binaries coordinate through a lock file and a unix socket in the OS temp
+// directory; the binary that started the dependency serves it to the others.
+package shared
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// StartFunc starts a shared dependency. It returns the dependency, which is
+// sent to the other test binaries as JSON, so should be connection details
+// rather than a connection, and a function to stop it.
+type StartFunc[D any] func(ctx context.Context) (D, func() error, error)
+
+// Options configures how a dependency is shared. The zero value is ready to
+// use.
+type Options struct {
+	// IdleTimeout is how long the dependency is kept after the last test
+	// binary releases it, for binaries that start later to pick up. It
+	// defaults to 5 seconds.
+	IdleTimeout time.Duration
+
+	// StartTimeout is how long to wait for another binary to start the
+	// dependency before deciding it died trying. It defaults to 5 minutes.
+	StartTimeout time.Duration
+
+	// Dir is where the lock file and socket are kept. It defaults to
+	// [os.TempDir]. Unix socket paths are limited to around 100 characters.
+	Dir string
+}
+
+// pollInterval is how often Acquire checks on a dependency another binary is
+// starting.
+const pollInterval = 50 * time.Millisecond
+
+// Acquire returns the dependency called name, starting it with start if no
+// other test binary has.
+//
+// release must be called once the dependency is no longer needed. In the
+// binary that started it, release waits for every other binary to release it
+// and the idle timeout to pass, then stops it.
+func Acquire[D any](ctx context.Context, name string, start StartFunc[D], opts Options) (d D, release func() error, err error) {
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = 5 * time.Second
+	}
+	if opts.StartTimeout == 0 {
+		opts.StartTimeout = 5 * time.Minute
+	}
+	if opts.Dir == "" {
+		opts.Dir = os.TempDir()
+	}
+
+	base := filepath.Join(opts.Dir, "sweet-shared-"+fileName(name))
+	lock, sock := base+".lock", base+".sock"
+
+	for {
+		if data, conn, err := dial(ctx, sock); err == nil {
+			if err := json.Unmarshal(data, &d); err != nil {
+				conn.Close()
+				return d, nil, fmt.Errorf("shared: decoding %s: %w", name, err)
+			}
+
+			return d, conn.Close, nil
+		}
+
+		if owned, err := takeLock(lock, sock, opts.StartTimeout); err != nil {
+			return d, nil, err
+		} else if owned {
+			return serve(ctx, name, lock, sock, start, opts)
+		}
+
+		select {
+		case <-ctx.Done():
+			return d, nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// NewPackageDep creates a [sweet.PackageDep] that is shared between test
+// binaries, for [sweet.Main] to set up.
+func NewPackageDep[D any](name string, start StartFunc[D], opts Options) *sweet.PackageDep[D] {
+	return sweet.NewPackageDep(name, func(p *sweet.Package) (D, error) {
+		d, release, err := Acquire(context.Background(), name, start, opts)
+		if err != nil {
+			return d, err
+		}
+
+		p.Cleanup(func() {
+			if err := release(); err != nil {
+				p.Logf("shared: releasing %s: %s", name, err)
+			}
+		})
+
+		return d, nil
+	})
+}
+
+// dial connects to the binary serving the dependency and reads it. The
+// connection is held open until the dependency is released.
+func dial(ctx context.Context, sock string) ([]byte, net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "unix", sock)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The server closes connections without a reply when it is shutting down.
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return line, conn, nil
+}
+
+// takeLock tries to become the binary serving the dependency. Locks left by
+// binaries that died are broken: ones with a dead socket, and ones that have
+// been starting for longer than startTimeout.
+func takeLock(lock, sock string, startTimeout time.Duration) (bool, error) {
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err == nil {
+		_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+
+		return err == nil, err
+	}
+
+	if !errors.Is(err, os.ErrExist) {
+		return false, fmt.Errorf("shared: creating lock: %w", err)
+	}
+
+	info, err := os.Stat(lock)
+	if err != nil {
+		// Released between trying to create and looking at it.
+		return false, nil
+	}
+
+	_, sockErr := os.Stat(sock)
+	serving := sockErr == nil
+	starting := !serving && time.Since(info.ModTime()) < startTimeout
+
+	if starting {
+		return false, nil
+	}
+
+	if serving {
+		// A live server accepts connections; it's only stale if dial fails.
+		conn, err := net.Dial("unix", sock)
+		if err == nil {
+			conn.Close()
+			return false, nil
+		}
+		_ = os.Remove(sock)
+	}
+
+	_ = os.Remove(lock)
+
+	return false, nil
+}
+
+// server serves the dependency to other test binaries and counts who is
+// using it.
+type server struct {
+	ln      net.Listener
+	payload []byte
+
+	mu      sync.Mutex
+	refs    int
+	closing bool
+	changed chan struct{}
+}
+
+func serve[D any](ctx context.Context, name, lock, sock string, start StartFunc[D], opts Options) (D, func() error, error) {
+	// A crashed server may have left its socket behind.
+	_ = os.Remove(sock)
+
+	d, stop, err := start(ctx)
+	if err != nil {
+		_ = os.Remove(lock)
+		return d, nil, fmt.Errorf("shared: starting %s: %w", name, err)
+	}
+
+	payload, err := json.Marshal(d)
+	if err != nil {
+		_ = stop()
+		_ = os.Remove(lock)
+		return d, nil, fmt.Errorf("shared: encoding %s: %w", name, err)
+	}
+
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		_ = stop()
+		_ = os.Remove(lock)
+		return d, nil, fmt.Errorf("shared: listening on %s: %w", sock, err)
+	}
+
+	s := &server{
+		ln:      ln,
+		payload: append(payload, '\n'),
+		refs:    1,
+		changed: make(chan struct{}, 1),
+	}
+	go s.accept()
+
+	release := func() error {
+		s.release()
+		s.waitIdle(opts.IdleTimeout)
+
+		// Freshen the lock so binaries waiting on it don't take it for one
+		// left by a binary that died, while the dependency stops.
+		now := time.Now()
+		_ = os.Chtimes(lock, now, now)
+
+		err := stop()
+		if rmErr := os.Remove(lock); err == nil && rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+			err = rmErr
+		}
+
+		return err
+	}
+
+	return d, release, nil
+}
+
+func (s *server) accept() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closing {
+			s.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		s.refs++
+		s.mu.Unlock()
+
+		go s.hold(conn)
+	}
+}
+
+// hold sends the dependency down conn and waits for the other side to
+// release it by closing the connection.
+func (s *server) hold(conn net.Conn) {
+	defer s.release()
+	defer conn.Close()
+
+	if _, err := conn.Write(s.payload); err != nil {
+		return
+	}
+
+	buf := make([]byte, 1)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			return
+		}
+	}
+}
+
+func (s *server) release() {
+	s.mu.Lock()
+	s.refs--
+	s.mu.Unlock()
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// waitIdle waits until nobody has used the dependency for idle, then stops
+// accepting connections.
+func (s *server) waitIdle(idle time.Duration) {
+	for {
+		s.mu.Lock()
+		refs := s.refs
+		s.mu.Unlock()
+
+		if refs > 0 {
+			<-s.changed
+			continue
+		}
+
+		select {
+		case <-s.changed:
+			continue
+		case <-time.After(idle):
+		}
+
+		s.mu.Lock()
+		if s.refs > 0 {
+			s.mu.Unlock()
+			continue
+		}
+		s.closing = true
+		s.mu.Unlock()
+
+		// Closing a unix listener removes its socket file.
+		s.ln.Close()
+		return
+	}
+}
+
+// fileName makes name safe to use in a file name.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,147 @@
+package shared_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet/shared"
+)
+
+type connInfo struct {
+	Addr string
+}
+
+// uniqueName gives each test its own dependency, so they don't share with
+// each other, or with other runs of the tests.
+func uniqueName() string {
+	return fmt.Sprintf("test-%d-%d", os.Getpid(), time.Now().UnixNano())
+}
+
+type counter struct {
+	starts, stops int32
+}
+
+func (c *counter) start(ctx context.Context) (connInfo, func() error, error) {
+	n := atomic.AddInt32(&c.starts, 1)
+
+	return connInfo{Addr: fmt.Sprintf("instance-%d", n)}, func() error {
+		atomic.AddInt32(&c.stops, 1)
+		return nil
+	}, nil
+}
+
+func TestAcquire(t *testing.T) {
+	ctx := context.Background()
+	opts := shared.Options{IdleTimeout: 50 * time.Millisecond}
+
+	t.Run("the second binary uses the first one's instance", func(t *testing.T) {
+		name := uniqueName()
+		c := &counter{}
+
+		first, releaseFirst, err := shared.Acquire(ctx, name, c.start, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		second, releaseSecond, err := shared.Acquire(ctx, name, c.start, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if first != second {
+			t.Errorf("the binaries got different instances: %v, %v", first, second)
+		}
+
+		if starts := atomic.LoadInt32(&c.starts); starts != 1 {
+			t.Errorf("expected 1 start, got %d", starts)
+		}
+
+		released := make(chan error)
+		go func() {
+			released <- releaseFirst()
+		}()
+
+		select {
+		case <-released:
+			t.Fatal("the instance was stopped while still in use")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		if err := releaseSecond(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := <-released; err != nil {
+			t.Fatal(err)
+		}
+
+		if stops := atomic.LoadInt32(&c.stops); stops != 1 {
+			t.Errorf("expected 1 stop, got %d", stops)
+		}
+	})
+
+	t.Run("is started again once stopped", func(t *testing.T) {
+		name := uniqueName()
+		c := &counter{}
+
+		for i := 0; i < 2; i++ {
+			_, release, err := shared.Acquire(ctx, name, c.start, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := release(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if starts := atomic.LoadInt32(&c.starts); starts != 2 {
+			t.Errorf("expected 2 starts, got %d", starts)
+		}
+	})
+
+	t.Run("breaks locks left by binaries that died", func(t *testing.T) {
+		name := uniqueName()
+		base := filepath.Join(os.TempDir(), "sweet-shared-"+name)
+
+		ln, err := net.Listen("unix", base+".sock")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln.(*net.UnixListener).SetUnlinkOnClose(false)
+		ln.Close()
+
+		if err := os.WriteFile(base+".lock", []byte("1\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		c := &counter{}
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		_, release, err := shared.Acquire(ctx, name, c.start, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := release(); err != nil {
+			t.Fatal(err)
+		}
+
+		if starts := atomic.LoadInt32(&c.starts); starts != 1 {
+			t.Errorf("expected 1 start, got %d", starts)
+		}
+
+		for _, f := range []string{base + ".lock", base + ".sock"} {
+			if _, err := os.Stat(f); !os.IsNotExist(err) {
+				t.Errorf("%s was left behind", f)
+			}
+		}
+	})
+}
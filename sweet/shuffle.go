@@ -0,0 +1,112 @@
+package sweet
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ShuffleEnv is the environment variable that turns on shuffling of the
+// subtests of every [Run] call.
+//
+// Set it to "on" to shuffle with a random seed, or to a number to replay the
+// order of a previous run with that seed. It only sets the seed of [Shuffle]
+// calls; their subtests are always shuffled.
+//
+// Subtests of plain tests, e.g. Run called straight from a TestXxx function,
+// are only shuffled in Shuffle: there is no telling when a plain test has
+// declared the last of them. go test -shuffle=on shuffles the TestXxx
+// functions themselves.
+const ShuffleEnv = "SWEET_SHUFFLE"
+
+var (
+	// shufflers holds the subtests deferred for each *testing.T that is
+	// currently being shuffled.
+	shufflers sync.Map
+
+	randomSeed     int64
+	randomSeedOnce sync.Once
+)
+
+type shuffler struct {
+	mu    sync.Mutex
+	names []string
+	tests []func(t *testing.T)
+}
+
+// Shuffle runs body, deferring every [Run] called directly on t until body
+// returns. The deferred subtests are then run in a seeded random order.
+//
+// Hidden ordering dependencies between tests, e.g. one test only passing
+// because an earlier sibling left some shared state behind, show up as
+// failures that come and go with the seed. The seed is logged so a failing
+// order can be replayed by setting [ShuffleEnv] to it.
+//
+// Setting [ShuffleEnv] also shuffles the subtests of every [Run] without
+// having to call Shuffle. The Run calls made straight from a TestXxx function
+// still need wrapping in Shuffle to be shuffled.
+func Shuffle(t *testing.T, body func(t *testing.T)) {
+	t.Helper()
+
+	s := &shuffler{}
+	shufflers.Store(t, s)
+	func() {
+		defer shufflers.Delete(t)
+		body(t)
+	}()
+
+	if len(s.tests) == 0 {
+		return
+	}
+
+	seed := shuffleSeed()
+	t.Logf("sweet: running %d subtests in shuffled order; replay with %s=%d", len(s.tests), ShuffleEnv, seed)
+
+	for _, i := range rand.New(rand.NewSource(seed)).Perm(len(s.tests)) {
+		t.Run(s.names[i], s.tests[i])
+	}
+}
+
+// deferRun queues test to be run later if t is being shuffled. It reports
+// whether the test was deferred.
+func deferRun(t *testing.T, name string, test func(t *testing.T)) bool {
+	v, ok := shufflers.Load(t)
+	if !ok {
+		return false
+	}
+
+	s := v.(*shuffler)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.names = append(s.names, name)
+	s.tests = append(s.tests, test)
+
+	return true
+}
+
+// shuffleAll reports whether [ShuffleEnv] asks for every [Run] to be shuffled.
+func shuffleAll() bool {
+	switch os.Getenv(ShuffleEnv) {
+	case "", "0", "off", "false":
+		return false
+	default:
+		return true
+	}
+}
+
+// shuffleSeed returns the seed given in [ShuffleEnv], or a random seed that is
+// fixed for the lifetime of the test binary.
+func shuffleSeed() int64 {
+	if seed, err := strconv.ParseInt(os.Getenv(ShuffleEnv), 10, 64); err == nil {
+		return seed
+	}
+
+	randomSeedOnce.Do(func() {
+		randomSeed = time.Now().UnixNano()
+	})
+
+	return randomSeed
+}
@@ -0,0 +1,106 @@
+package sweet_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+func TestShuffle(t *testing.T) {
+	runOrder := func(t *testing.T) []int {
+		order := []int{}
+
+		sweet.Shuffle(t, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				i := i
+				sweet.Run(t, fmt.Sprintf("test %d", i), nil, func(t *testing.T, d any) {
+					order = append(order, i)
+				})
+			}
+
+			if len(order) != 0 {
+				t.Errorf("subtests ran before all siblings were declared: %v", order)
+			}
+		})
+
+		return order
+	}
+
+	t.Run("runs every subtest", func(t *testing.T) {
+		order := runOrder(t)
+
+		if len(order) != 10 {
+			t.Fatalf("expected 10 subtests to run, %d did", len(order))
+		}
+
+		seen := map[int]bool{}
+		for _, i := range order {
+			if seen[i] {
+				t.Errorf("subtest %d ran twice", i)
+			}
+			seen[i] = true
+		}
+	})
+
+	t.Run("the same seed gives the same order", func(t *testing.T) {
+		t.Setenv(sweet.ShuffleEnv, "42")
+
+		first := runOrder(t)
+		second := runOrder(t)
+
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("orders differ for the same seed: %v != %v", first, second)
+		}
+	})
+
+	t.Run("a fixed seed shuffles the declaration order", func(t *testing.T) {
+		t.Setenv(sweet.ShuffleEnv, "42")
+
+		order := runOrder(t)
+
+		if reflect.DeepEqual(order, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+			t.Errorf("expected the subtests to run out of the order they were declared in, got %v", order)
+		}
+	})
+
+	t.Run("nested runs are shuffled when enabled by the environment", func(t *testing.T) {
+		t.Setenv(sweet.ShuffleEnv, "on")
+
+		ran := 0
+		sweet.Run(t, "outer", nil, func(t *testing.T, d any) {
+			for i := 0; i < 3; i++ {
+				sweet.Run(t, fmt.Sprintf("inner %d", i), nil, func(t *testing.T, d any) {
+					ran++
+				})
+			}
+
+			if ran != 0 {
+				t.Errorf("%d subtests ran before all siblings were declared", ran)
+			}
+		})
+
+		if ran != 3 {
+			t.Errorf("expected 3 subtests to run, %d did", ran)
+		}
+	})
+
+	t.Run("nested runs are shuffled by a fixed seed in the environment", func(t *testing.T) {
+		t.Setenv(sweet.ShuffleEnv, "42")
+
+		order := []int{}
+		sweet.Run(t, "outer", nil, func(t *testing.T, d any) {
+			for i := 0; i < 10; i++ {
+				i := i
+				sweet.Run(t, fmt.Sprintf("inner %d", i), nil, func(t *testing.T, d any) {
+					order = append(order, i)
+				})
+			}
+		})
+
+		if len(order) != 10 || reflect.DeepEqual(order, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+			t.Errorf("expected the 10 subtests to run out of the order they were declared in, got %v", order)
+		}
+	})
+}
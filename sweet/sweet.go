@@ -16,17 +16,70 @@ type DepFactory[deps any] func(t *testing.T) deps
 //
 //	t.Run("subtest name", func(t *testing.T) {...})
 //	sweet.Run(t, "subtest name", func(t *testing.T) deps, func(t *testing.T, d deps) {...})
+//
+// Dependencies, and the exported fields of dependency structs, that implement
+// [io.Closer] are closed when the test ends. Those implementing [Verifier]
+// are verified when the test body returns and those implementing [Resetter]
+// are reset before it runs. Fields opt out with a `sweet:"-"` tag, or of
+// single hooks with `sweet:"noclose"`, `sweet:"noverify"` or `sweet:"noreset"`.
+// Values made by a factory wrapped in [NoClose] aren't closed.
+//
+// If the test fails, hooks registered with [OnFailure] are called before the
+// dependencies are cleaned up.
+//
+// If [EventsEnv] is set, the steps of the test are written to it as [Event]s,
+// and any [Observer]s are told of them.
+//
+// If t is being shuffled (see [Shuffle]) the subtest is deferred until its
+// siblings have all been declared, and Run returns true straight away.
 func Run[deps any, ptrDeps *deps](
 	t *testing.T,
 	testName string,
 	factory DepFactory[deps],
 	coreTest func(t *testing.T, d deps),
 ) bool {
-	return t.Run(testName, func(t *testing.T) {
+	setTestedPackage()
+	test := newTest[deps, ptrDeps](t, factory, coreTest)
+
+	if deferRun(t, testName, test) {
+		return true
+	}
+
+	return t.Run(testName, test)
+}
+
+// newTest creates the function a subtest of parent runs: building the deps,
+// running coreTest with them and everything in between.
+func newTest[deps any, ptrDeps *deps](
+	parent *testing.T,
+	factory DepFactory[deps],
+	coreTest func(t *testing.T, d deps),
+) func(t *testing.T) {
+	return func(t *testing.T) {
+		defer teardownOnPanic()
+		defer startEvents(t, parent)()
+		defer cancelContext(t)
+		defer runDiagnostics(t)
+
+		var d deps
 		if factory != nil {
-			coreTest(t, factory(t))
+			d = build(t, factory)
 		} else {
-			coreTest(t, *ptrDeps(new(deps)))
+			d = *ptrDeps(new(deps))
 		}
-	})
+
+		h := findHooks(t, d)
+		h.reset(t)
+		defer h.verify(t)
+
+		inStep(t, ActionBodyStart, ActionBodyEnd, func() {
+			if shuffleAll() {
+				Shuffle(t, func(t *testing.T) {
+					coreTest(t, d)
+				})
+			} else {
+				coreTest(t, d)
+			}
+		})
+	}
 }
@@ -0,0 +1,80 @@
+package sweetotel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewFileExporter creates an exporter that appends spans to the file at path
+// as OTLP JSON, one export request per line. It is the format of the
+// OpenTelemetry collector's file exporter, which its otlpjsonfile receiver
+// reads back.
+func NewFileExporter(ctx context.Context, path string) (*otlptrace.Exporter, error) {
+	return otlptrace.New(ctx, &fileClient{path: path})
+}
+
+// fileClient is an otlptrace.Client writing to a file.
+type fileClient struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+func (c *fileClient) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	c.f = f
+	return nil
+}
+
+func (c *fileClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.f == nil {
+		return nil
+	}
+	err := c.f.Close()
+	c.f = nil
+	return err
+}
+
+func (c *fileClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	b := &bytes.Buffer{}
+	b.WriteString(`{"resourceSpans":[`)
+	for i, rs := range spans {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		data, err := protojson.Marshal(rs)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+	b.WriteString("]}\n")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.f == nil {
+		return errors.New("sweetotel: exporter is not started")
+	}
+	// One write per request keeps requests from several test binaries,
+	// appending to the same file, whole.
+	_, err := c.f.Write(b.Bytes())
+	return err
+}
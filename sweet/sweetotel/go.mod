@@ -0,0 +1,19 @@
+module github.com/barry-hennessy/test/sweet/sweetotel
+
+go 1.18
+
+require (
+	github.com/barry-hennessy/test/sweet v0.0.0-20230211170601-72d98e494882
+	go.opentelemetry.io/otel v1.11.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.11.0
+	go.opentelemetry.io/otel/sdk v1.11.0
+	go.opentelemetry.io/otel/trace v1.11.0
+	go.opentelemetry.io/proto/otlp v0.19.0
+	google.golang.org/protobuf v1.28.1
+)
+
+require (
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+)
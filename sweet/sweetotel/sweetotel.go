@@ -0,0 +1,122 @@
+// Package sweetotel traces tests run with sweet.Run with OpenTelemetry.
+//
+// Each test gets a span, with child spans for its factory building its
+// dependencies, its body, its failure diagnostics and its cleanups. A test's
+// sweet.Context carries the span of the step it is in, so spans started by
+// the code under test, from that context, nest under the test that triggered
+// them:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(sweet.Main(m, sweetotel.NewPackageDep(nil)))
+//	}
+//
+// Spans are appended to the file named by [FileEnv] as OTLP JSON, or sent
+// wherever the exporter passed to [NewPackageDep] sends them, e.g. an OTLP
+// collector.
+package sweetotel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/barry-hennessy/test/sweet"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FileEnv is the environment variable naming a file for [NewPackageDep] to
+// append spans to, when it is not given an exporter.
+const FileEnv = "SWEET_OTEL_FILE"
+
+// instrumentation names the tracer of test spans.
+const instrumentation = "github.com/barry-hennessy/test/sweet/sweetotel"
+
+// The attributes of test spans.
+const (
+	TestKey    = attribute.Key("sweet.test")
+	PackageKey = attribute.Key("sweet.package")
+)
+
+// Trace traces every test run with sweet.Run with a tracer from tp, until the
+// returned function is called.
+func Trace(tp trace.TracerProvider) (stop func()) {
+	return sweet.Observe(observer{tracer: tp.Tracer(instrumentation)})
+}
+
+// NewPackageDep creates a package dependency, to pass to sweet.Main, that
+// traces every test run with sweet.Run and flushes the spans once the tests
+// are done.
+//
+// Spans are exported with the exporter newExporter creates, e.g. with
+// otlptracehttp.New to send them to a collector. If newExporter is nil they
+// are appended to the file named by [FileEnv], or dropped if it is not set.
+func NewPackageDep(newExporter func(ctx context.Context) (sdktrace.SpanExporter, error)) *sweet.PackageDep[*sdktrace.TracerProvider] {
+	return sweet.NewPackageDep("tracing", func(p *sweet.Package) (*sdktrace.TracerProvider, error) {
+		ctx := context.Background()
+
+		opts := []sdktrace.TracerProviderOption{
+			sdktrace.WithResource(resource.NewSchemaless(
+				attribute.String("service.name", filepath.Base(os.Args[0])),
+			)),
+		}
+
+		if newExporter == nil {
+			if path := os.Getenv(FileEnv); path != "" {
+				newExporter = func(ctx context.Context) (sdktrace.SpanExporter, error) {
+					return NewFileExporter(ctx, path)
+				}
+			}
+		}
+		if newExporter != nil {
+			exporter, err := newExporter(ctx)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, sdktrace.WithBatcher(exporter))
+		}
+
+		tp := sdktrace.NewTracerProvider(opts...)
+		p.Cleanup(func() {
+			if err := tp.Shutdown(ctx); err != nil {
+				p.Logf("sweetotel: flushing spans: %s", err)
+			}
+		})
+		p.Cleanup(Trace(tp))
+
+		return tp, nil
+	})
+}
+
+// observer starts a span for each step of a test.
+type observer struct {
+	tracer trace.Tracer
+}
+
+func (o observer) StartStep(ctx context.Context, ev sweet.Event) context.Context {
+	name := ev.Test
+	if ev.Action != sweet.ActionTestStart {
+		name = strings.TrimSuffix(ev.Action, "-start")
+	}
+
+	ctx, _ = o.tracer.Start(ctx, name,
+		trace.WithTimestamp(ev.Time),
+		trace.WithAttributes(
+			TestKey.String(ev.Test),
+			PackageKey.String(ev.Package),
+		),
+	)
+	return ctx
+}
+
+func (o observer) EndStep(ctx context.Context, ev sweet.Event) {
+	span := trace.SpanFromContext(ctx)
+	if ev.Failed {
+		span.SetStatus(codes.Error, "test failed")
+	}
+	span.End(trace.WithTimestamp(ev.Time))
+}
@@ -0,0 +1,122 @@
+package sweetotel_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+	"github.com/barry-hennessy/test/sweet/sweetotel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	stop := sweetotel.Trace(tp)
+	defer stop()
+
+	factory := func(t *testing.T) int {
+		t.Cleanup(func() {})
+		return 1
+	}
+	sweet.Run(t, "traced", factory, func(t *testing.T, d int) {
+		// The code under test, tracing from the test's context.
+		_, span := tp.Tracer("code under test").Start(sweet.Context(t), "query")
+		span.End()
+	})
+	stop()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	test, ok := spans["TestTrace/traced"]
+	if !ok {
+		t.Fatalf("no span for the test, got %v", spanNames(recorder.Ended()))
+	}
+
+	parents := map[string]string{
+		"factory": "TestTrace/traced",
+		"body":    "TestTrace/traced",
+		"cleanup": "TestTrace/traced",
+		"query":   "body",
+	}
+	for name, parent := range parents {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span, got %v", name, spanNames(recorder.Ended()))
+			continue
+		}
+		if span.Parent().SpanID() != spans[parent].SpanContext().SpanID() {
+			t.Errorf("expected the %s span to be a child of the %s span", name, parent)
+		}
+		if span.SpanContext().TraceID() != test.SpanContext().TraceID() {
+			t.Errorf("expected the %s span in the test's trace", name)
+		}
+	}
+
+	if test.Status().Code == codes.Error {
+		t.Error("expected the passing test's span not to be an error")
+	}
+}
+
+func TestNewFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.json")
+
+	exporter, err := sweetotel.NewFileExporter(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "exported")
+	span.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name string `json:"name"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("no spans were written")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+		t.Fatalf("the spans are not OTLP JSON: %s\n%s", err, scanner.Bytes())
+	}
+
+	if len(request.ResourceSpans) != 1 ||
+		len(request.ResourceSpans[0].ScopeSpans) != 1 ||
+		len(request.ResourceSpans[0].ScopeSpans[0].Spans) != 1 ||
+		request.ResourceSpans[0].ScopeSpans[0].Spans[0].Name != "exported" {
+		t.Fatalf("expected the exported span, got: %s", scanner.Bytes())
+	}
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	return names
+}
@@ -0,0 +1,259 @@
+// Package sweettest checks that [sweet.DepFactory] implementations honour
+// sweet's contract:
+//   - every test gets a fresh value, sharing no state with any other test
+//   - everything a value holds is cleaned up when its test ends, and not before
+//   - the factory is safe to call from parallel tests
+//   - nothing is left running once all the tests are done
+//
+// Certify a factory by calling [CheckFactory] from a test in its package.
+package sweettest
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+// Options configures [CheckFactory]. The zero value is ready to use.
+type Options[D any] struct {
+	// Calls is the number of values made for each check. It defaults to 3,
+	// and must be at least 2 for the values to be compared.
+	Calls int
+
+	// Released reports whether everything d holds has been cleaned up. It is
+	// called once the test that made d has ended, and while an outer test
+	// holding d is still running.
+	//
+	// Without it only the values themselves are checked, not their clean up.
+	Released func(d D) bool
+
+	// Same reports whether a and b share any state. By default they do if
+	// they, or any of their top level fields, point at the same thing.
+	Same func(a, b D) bool
+
+	// LeakTimeout is how long to wait for goroutines started by the factory
+	// to exit before reporting them as leaked. It defaults to a second.
+	LeakTimeout time.Duration
+
+	// IgnoreGoroutines are substrings of the stacks of goroutines that are
+	// not leaks, e.g. "net/http.(*persistConn)" for pooled connections.
+	IgnoreGoroutines []string
+}
+
+// CheckFactory calls factory repeatedly, in sequence, in nested and in
+// parallel tests, and fails t if it breaks sweet's contract.
+//
+// It counts goroutines to find leaks, so t should not run in parallel with
+// other tests.
+func CheckFactory[D any](t *testing.T, factory sweet.DepFactory[D], opts Options[D]) {
+	t.Helper()
+
+	switch {
+	case opts.Calls == 0:
+		opts.Calls = 3
+	case opts.Calls < 2:
+		t.Fatalf("sweettest: Calls is %d; it must be at least 2 to compare values", opts.Calls)
+	}
+	same := func(a, b D) (string, bool) {
+		return "", opts.Same(a, b)
+	}
+	if opts.Same == nil {
+		same = func(a, b D) (string, bool) {
+			return sharedState(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+		}
+	}
+	if opts.LeakTimeout == 0 {
+		opts.LeakTimeout = time.Second
+	}
+
+	goroutines, _ := countGoroutines(opts.IgnoreGoroutines)
+
+	t.Run("fresh values", func(t *testing.T) {
+		values := []D{}
+		for i := 0; i < opts.Calls; i++ {
+			sweet.Run(t, fmt.Sprintf("call %d", i), factory, func(t *testing.T, d D) {
+				values = append(values, d)
+			})
+		}
+
+		checkDistinct(t, same, values)
+		checkReleased(t, opts, values...)
+	})
+
+	t.Run("nested scopes", func(t *testing.T) {
+		var inner D
+		sweet.Run(t, "outer", factory, func(t *testing.T, outer D) {
+			sweet.Run(t, "inner", factory, func(t *testing.T, d D) {
+				inner = d
+			})
+
+			checkDistinct(t, same, []D{outer, inner})
+			checkReleased(t, opts, inner)
+
+			if opts.Released != nil && opts.Released(outer) {
+				t.Error("the outer value was cleaned up when the inner test ended")
+			}
+		})
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		mu := sync.Mutex{}
+		values := []D{}
+
+		t.Run("group", func(t *testing.T) {
+			for i := 0; i < opts.Calls; i++ {
+				// Parallel before the factory is called, so the factories run
+				// in parallel, not just the tests.
+				parallelFactory := func(t *testing.T) D {
+					t.Parallel()
+					return factory(t)
+				}
+
+				sweet.Run(t, fmt.Sprintf("call %d", i), parallelFactory, func(t *testing.T, d D) {
+					mu.Lock()
+					defer mu.Unlock()
+					values = append(values, d)
+				})
+			}
+		})
+
+		checkDistinct(t, same, values)
+		checkReleased(t, opts, values...)
+	})
+
+	// Checked here, rather than in a subtest of its own, so the count isn't
+	// thrown off by the subtest's goroutine.
+	deadline := time.Now().Add(opts.LeakTimeout)
+	for {
+		now, stacks := countGoroutines(opts.IgnoreGoroutines)
+		if now <= goroutines {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Errorf("%d goroutine(s) were left running by the factory:\n\n%s", now-goroutines, stacks)
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// countGoroutines counts the running goroutines, other than those with a
+// stack containing one of ignore, and returns their stacks.
+func countGoroutines(ignore []string) (int, string) {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	stacks := []string{}
+next:
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		for _, ig := range ignore {
+			if strings.Contains(stack, ig) {
+				continue next
+			}
+		}
+		stacks = append(stacks, stack)
+	}
+
+	return len(stacks), strings.Join(stacks, "\n\n")
+}
+
+func checkDistinct[D any](t *testing.T, same func(a, b D) (string, bool), values []D) {
+	t.Helper()
+
+	for i := range values {
+		for j := i + 1; j < len(values); j++ {
+			path, shared := same(values[i], values[j])
+			switch {
+			case shared && path != "":
+				t.Errorf("calls %d and %d returned values sharing state at %s", i, j, path)
+			case shared:
+				t.Errorf("calls %d and %d returned values sharing state", i, j)
+			}
+		}
+	}
+}
+
+func checkReleased[D any](t *testing.T, opts Options[D], values ...D) {
+	t.Helper()
+
+	if opts.Released == nil {
+		return
+	}
+
+	for i, v := range values {
+		if !opts.Released(v) {
+			t.Errorf("the value from call %d was not cleaned up after its test", i)
+		}
+	}
+}
+
+// sharedState reports whether a and b, or any of their top level fields,
+// point at the same thing, and if so the path to it: "d" for the values
+// themselves, "d.Field" for a field.
+func sharedState(a, b reflect.Value) (string, bool) {
+	a, b = unwrap(a), unwrap(b)
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		return "", false
+	}
+
+	if samePointer(a, b) {
+		return "d", true
+	}
+
+	if a.Kind() == reflect.Ptr {
+		a, b = a.Elem(), b.Elem()
+	}
+
+	if a.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	for i := 0; i < a.NumField(); i++ {
+		if samePointer(unwrap(a.Field(i)), unwrap(b.Field(i))) {
+			return "d." + a.Type().Field(i).Name, true
+		}
+	}
+
+	return "", false
+}
+
+// unwrap returns the value held by an interface.
+func unwrap(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// samePointer reports whether a and b are non nil references to the same
+// thing. Functions are never the same, closures share their code pointer.
+func samePointer(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return !a.IsNil() && a.Pointer() == b.Pointer()
+	case reflect.Slice:
+		return a.Len() > 0 && b.Len() > 0 && a.Pointer() == b.Pointer()
+	default:
+		return false
+	}
+}
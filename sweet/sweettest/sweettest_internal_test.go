@@ -0,0 +1,56 @@
+package sweettest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type database struct {
+	name string
+}
+
+type deps struct {
+	DB      *database
+	Cache   map[string]string
+	Created time.Time
+	Name    string
+}
+
+func TestSharedState(t *testing.T) {
+	db := &database{}
+
+	cases := map[string]struct {
+		a, b   any
+		path   string
+		shared bool
+	}{
+		"distinct pointers": {
+			a: &database{}, b: &database{},
+		},
+		"the same pointer": {
+			a: db, b: db, path: "d", shared: true,
+		},
+		"a shared field": {
+			a:    deps{DB: db, Cache: map[string]string{}},
+			b:    deps{DB: db, Cache: map[string]string{}},
+			path: "d.DB", shared: true,
+		},
+		"a shared field behind a pointer": {
+			a:    &deps{DB: db, Cache: map[string]string{}},
+			b:    &deps{DB: db, Cache: map[string]string{}},
+			path: "d.DB", shared: true,
+		},
+		"equal values don't share state": {
+			a: deps{Name: "a", Created: time.Now()}, b: deps{Name: "a", Created: time.Now()},
+		},
+	}
+
+	for name, c := range cases {
+		path, shared := sharedState(reflect.ValueOf(c.a), reflect.ValueOf(c.b))
+
+		if shared != c.shared || path != c.path {
+			t.Errorf("%s: expected (%q, %v), got (%q, %v)", name, c.path, c.shared, path, shared)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package sweettest_test
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/barry-hennessy/test/sweet/sweettest"
+)
+
+type connection struct {
+	open bool
+	done chan struct{}
+}
+
+func connectionFactory(t *testing.T) *connection {
+	c := &connection{open: true, done: make(chan struct{})}
+
+	go func() {
+		<-c.done
+	}()
+
+	t.Cleanup(func() {
+		c.open = false
+		close(c.done)
+	})
+
+	return c
+}
+
+func TestCheckFactory(t *testing.T) {
+	sweettest.CheckFactory(t, connectionFactory, sweettest.Options[*connection]{
+		Released: func(c *connection) bool {
+			return !c.open
+		},
+	})
+}
+
+// failingEnv marks the child process run by runFailing.
+const failingEnv = "SWEETTEST_TEST_FAILING"
+
+// runFailing runs the test named name in a child process, where it is
+// expected to fail, and returns its output.
+func runFailing(t *testing.T, name string) string {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^"+name+"$", "-test.v", "-test.count=1")
+	cmd.Env = append(os.Environ(), failingEnv+"=1")
+
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("expected %s to fail, got %v:\n%s", name, err, out)
+	}
+
+	return string(out)
+}
+
+// skipUnlessFailing skips tests that are only meant to be run, and fail, via
+// runFailing.
+func skipUnlessFailing(t *testing.T) {
+	if os.Getenv(failingEnv) == "" {
+		t.Skip("only run by runFailing")
+	}
+}
+
+type pool struct {
+	conns *connection
+}
+
+func TestCheckFactory_sharedState(t *testing.T) {
+	out := runFailing(t, "TestCheckFactory_sharedStateFails")
+
+	if !strings.Contains(out, "calls 0 and 1 returned values sharing state at d.conns") {
+		t.Errorf("expected the shared field to be reported, got:\n%s", out)
+	}
+}
+
+func TestCheckFactory_sharedStateFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	shared := &connection{open: true}
+	sweettest.CheckFactory(t, func(t *testing.T) pool {
+		return pool{conns: shared}
+	}, sweettest.Options[pool]{})
+}
+
+func TestCheckFactory_unreleased(t *testing.T) {
+	out := runFailing(t, "TestCheckFactory_unreleasedFails")
+
+	if !strings.Contains(out, "the value from call 0 was not cleaned up after its test") {
+		t.Errorf("expected the unreleased value to be reported, got:\n%s", out)
+	}
+}
+
+func TestCheckFactory_unreleasedFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweettest.CheckFactory(t, func(t *testing.T) *connection {
+		return &connection{open: true}
+	}, sweettest.Options[*connection]{
+		Released: func(c *connection) bool {
+			return !c.open
+		},
+	})
+}
+
+func TestCheckFactory_leak(t *testing.T) {
+	out := runFailing(t, "TestCheckFactory_leakFails")
+
+	if !strings.Contains(out, "goroutine(s) were left running by the factory") ||
+		!strings.Contains(out, "sweettest_test.TestCheckFactory_leakFails") {
+		t.Errorf("expected the leaked goroutines to be reported, got:\n%s", out)
+	}
+}
+
+func TestCheckFactory_leakFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweettest.CheckFactory(t, func(t *testing.T) *connection {
+		c := &connection{open: true, done: make(chan struct{})}
+		go func() {
+			<-c.done
+		}()
+		return c
+	}, sweettest.Options[*connection]{LeakTimeout: 50 * time.Millisecond})
+}
+
+func TestCheckFactory_calls(t *testing.T) {
+	out := runFailing(t, "TestCheckFactory_callsFails")
+
+	if !strings.Contains(out, "sweettest: Calls is 1; it must be at least 2 to compare values") {
+		t.Errorf("expected too few calls to be rejected, got:\n%s", out)
+	}
+}
+
+func TestCheckFactory_callsFails(t *testing.T) {
+	skipUnlessFailing(t)
+
+	sweettest.CheckFactory(t, connectionFactory, sweettest.Options[*connection]{Calls: 1})
+}
+
+func TestCheckFactory_parallel(t *testing.T) {
+	if f := flag.Lookup("test.parallel"); f == nil || f.Value.String() == "1" {
+		t.Skip("parallel tests run one at a time; run with -parallel 2 or more")
+	}
+
+	var mu sync.Mutex
+	running, most := 0, 0
+
+	sweettest.CheckFactory(t, func(t *testing.T) *connection {
+		mu.Lock()
+		running++
+		if running > most {
+			most = running
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		return &connection{}
+	}, sweettest.Options[*connection]{})
+
+	if most < 2 {
+		t.Errorf("expected the factory to be called from parallel tests at once, at most %d were", most)
+	}
+}
@@ -0,0 +1,17 @@
+// Command sweetvet reports common mistakes made with sweet. See the sweetvet
+// package for what it checks.
+//
+// Run it on its own, or through go vet:
+//
+//	sweetvet ./...
+//	go vet -vettool=$(which sweetvet) ./...
+package main
+
+import (
+	"github.com/barry-hennessy/test/sweet/sweetvet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(sweetvet.Analyzer)
+}
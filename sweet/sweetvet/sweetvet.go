@@ -0,0 +1,653 @@
+// Package sweetvet defines an [analysis.Analyzer] that reports common
+// mistakes made with sweet:
+//
+//   - the deps parameter of a nested sweet.Run test shadowing the deps of the
+//     test it is nested in
+//   - nested sweet.Run tests writing to the deps of the test they are nested
+//     in, which all of the nested tests share
+//   - factories creating resources, like files or connections, that nothing
+//     closes; no [testing.T.Cleanup] is registered, they are not closed in the
+//     factory and sweet does not close them with the dependency
+//   - factories using a context from outside the test, like
+//     [context.Background] or one they captured, rather than sweet.Context(t)
+//
+// A factory is a function used as a sweet.DepFactory: passed to sweet.Run,
+// or anything else taking one, or returned, assigned or converted to one.
+//
+// Run it with go vet through the sweetvet command:
+//
+//	go install github.com/barry-hennessy/test/sweet/sweetvet/cmd/sweetvet@latest
+//	go vet -vettool=$(which sweetvet) ./...
+//
+// or load [Analyzer] into golangci-lint as a custom linter.
+package sweetvet
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const sweetPath = "github.com/barry-hennessy/test/sweet"
+
+// Analyzer reports common mistakes made with sweet.
+var Analyzer = &analysis.Analyzer{
+	Name:     "sweetvet",
+	Doc:      "report common mistakes made with sweet tests and dependency factories",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	checkNestedRuns(pass, inspect)
+	if pass.Pkg.Path() != sweetPath {
+		// sweet's own helpers, like Context, look like factories.
+		checkFactories(pass, inspect)
+	}
+
+	return nil, nil
+}
+
+// runBody is the test function passed to a sweet.Run call.
+type runBody struct {
+	lit  *ast.FuncLit
+	deps *types.Var
+}
+
+// checkNestedRuns reports nested sweet.Run tests that shadow or write to the
+// deps of the tests they are nested in.
+func checkNestedRuns(pass *analysis.Pass, inspect *inspector.Inspector) {
+	bodies := map[*ast.FuncLit]runBody{}
+	byDeps := map[*types.Var]runBody{}
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if !isSweetFunc(pass, call, "Run") || len(call.Args) != 4 {
+			return
+		}
+		lit, ok := astutil.Unparen(call.Args[3]).(*ast.FuncLit)
+		if !ok {
+			return
+		}
+
+		body := runBody{lit: lit, deps: depsParam(pass, lit)}
+		bodies[lit] = body
+		if body.deps != nil {
+			byDeps[body.deps] = body
+		}
+	})
+
+	// enclosing returns the bodies of the sweet.Run tests the node at the top
+	// of stack is in, innermost first.
+	enclosing := func(stack []ast.Node) []runBody {
+		var in []runBody
+		for i := len(stack) - 1; i >= 0; i-- {
+			if lit, ok := stack[i].(*ast.FuncLit); ok {
+				if body, ok := bodies[lit]; ok {
+					in = append(in, body)
+				}
+			}
+		}
+		return in
+	}
+
+	nodes := []ast.Node{
+		(*ast.FuncLit)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.IncDecStmt)(nil),
+	}
+	inspect.WithStack(nodes, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			body, ok := bodies[n]
+			if !ok || body.deps == nil {
+				return true
+			}
+			for _, outer := range enclosing(stack[:len(stack)-1]) {
+				if outer.deps != nil && outer.deps.Name() == body.deps.Name() {
+					pass.Reportf(body.deps.Pos(),
+						"deps parameter %s shadows the deps of the enclosing sweet.Run test",
+						body.deps.Name())
+					break
+				}
+			}
+
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				return true
+			}
+			for _, lhs := range n.Lhs {
+				checkWrite(pass, lhs, enclosing(stack), byDeps)
+			}
+
+		case *ast.IncDecStmt:
+			checkWrite(pass, n.X, enclosing(stack), byDeps)
+		}
+
+		return true
+	})
+}
+
+// checkWrite reports expr if it writes to the deps of a sweet.Run test other
+// than the innermost one it is in.
+func checkWrite(pass *analysis.Pass, expr ast.Expr, in []runBody, byDeps map[*types.Var]runBody) {
+	if len(in) < 2 {
+		return
+	}
+
+	id := rootIdent(expr)
+	if id == nil {
+		return
+	}
+	v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok {
+		return
+	}
+	if _, ok := byDeps[v]; !ok || v == in[0].deps {
+		return
+	}
+
+	pass.Reportf(expr.Pos(),
+		"nested sweet.Run test writes to %s, the deps of an enclosing test; they are shared by every nested test",
+		id.Name)
+}
+
+// checkFactories reports factories that leave resources open or use a
+// context from outside the test.
+func checkFactories(pass *analysis.Pass, inspect *inspector.Inspector) {
+	factories := findFactories(pass, inspect)
+
+	nodes := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+	}
+	inspect.Preorder(nodes, func(n ast.Node) {
+		var (
+			sig  *types.Signature
+			body *ast.BlockStmt
+		)
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			fn, ok := pass.TypesInfo.Defs[n.Name].(*types.Func)
+			if !ok || !factories.funcs[fn] {
+				return
+			}
+			sig = fn.Type().(*types.Signature)
+			body = n.Body
+		case *ast.FuncLit:
+			if !factories.lits[n] {
+				return
+			}
+			sig, _ = pass.TypesInfo.TypeOf(n).(*types.Signature)
+			body = n.Body
+		}
+		if sig == nil || body == nil || !isFactorySig(sig) {
+			return
+		}
+
+		f := factory{
+			pass: pass,
+			node: n,
+			body: body,
+			t:    sig.Params().At(0),
+			dep:  sig.Results().At(0).Type(),
+		}
+		f.checkCleanup()
+		f.checkContext()
+	})
+}
+
+// factories are the functions of a package used as a sweet.DepFactory.
+type factories struct {
+	funcs map[*types.Func]bool
+	lits  map[*ast.FuncLit]bool
+}
+
+// findFactories finds the functions used as a sweet.DepFactory: passed to
+// sweet.Run, or any other function taking one, or returned, assigned or
+// converted to one. Other functions taking a *testing.T, like test helpers,
+// aren't factories even if they return something.
+func findFactories(pass *analysis.Pass, inspect *inspector.Inspector) factories {
+	found := factories{
+		funcs: map[*types.Func]bool{},
+		lits:  map[*ast.FuncLit]bool{},
+	}
+
+	add := func(expr ast.Expr, typ types.Type) {
+		if !isDepFactory(typ) {
+			return
+		}
+		switch e := astutil.Unparen(expr).(type) {
+		case *ast.FuncLit:
+			found.lits[e] = true
+		case *ast.Ident:
+			if fn, ok := pass.TypesInfo.Uses[e].(*types.Func); ok {
+				found.funcs[fn] = true
+			}
+		}
+	}
+
+	nodes := []ast.Node{
+		(*ast.CallExpr)(nil),
+		(*ast.ReturnStmt)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.CompositeLit)(nil),
+	}
+	inspect.WithStack(nodes, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			fun := pass.TypesInfo.Types[n.Fun]
+			if fun.IsType() {
+				if len(n.Args) == 1 {
+					add(n.Args[0], fun.Type)
+				}
+				return true
+			}
+			sig, ok := fun.Type.(*types.Signature)
+			if !ok {
+				return true
+			}
+			for i, arg := range n.Args {
+				add(arg, paramType(sig, i))
+			}
+
+		case *ast.ReturnStmt:
+			results := enclosingResults(pass, stack)
+			if results == nil || results.Len() != len(n.Results) {
+				return true
+			}
+			for i, res := range n.Results {
+				add(res, results.At(i).Type())
+			}
+
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				add(n.Rhs[i], pass.TypesInfo.TypeOf(lhs))
+			}
+
+		case *ast.ValueSpec:
+			if n.Type == nil {
+				return true
+			}
+			for _, value := range n.Values {
+				add(value, pass.TypesInfo.TypeOf(n.Type))
+			}
+
+		case *ast.CompositeLit:
+			typ := pass.TypesInfo.TypeOf(n)
+			if typ == nil {
+				return true
+			}
+			for i, elt := range n.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					add(kv.Value, elementType(typ, kv.Key, i))
+				} else {
+					add(elt, elementType(typ, nil, i))
+				}
+			}
+		}
+
+		return true
+	})
+
+	return found
+}
+
+// paramType returns the type of the i'th argument passed to a function of
+// type sig.
+func paramType(sig *types.Signature, i int) types.Type {
+	params := sig.Params()
+	if sig.Variadic() && i >= params.Len()-1 {
+		if slice, ok := params.At(params.Len() - 1).Type().(*types.Slice); ok {
+			return slice.Elem()
+		}
+		return nil
+	}
+	if i >= params.Len() {
+		return nil
+	}
+	return params.At(i).Type()
+}
+
+// enclosingResults returns the results of the function the node at the top
+// of stack returns from.
+func enclosingResults(pass *analysis.Pass, stack []ast.Node) *types.Tuple {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncLit:
+			if sig, ok := pass.TypesInfo.TypeOf(fn).(*types.Signature); ok {
+				return sig.Results()
+			}
+			return nil
+		case *ast.FuncDecl:
+			if obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+				return obj.Type().(*types.Signature).Results()
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// elementType returns the type of the i'th element, with key, of a composite
+// literal of type typ.
+func elementType(typ types.Type, key ast.Expr, i int) types.Type {
+	switch t := typ.Underlying().(type) {
+	case *types.Map:
+		return t.Elem()
+	case *types.Slice:
+		return t.Elem()
+	case *types.Array:
+		return t.Elem()
+	case *types.Pointer:
+		return elementType(t.Elem(), key, i)
+	case *types.Struct:
+		if id, ok := key.(*ast.Ident); ok {
+			for j := 0; j < t.NumFields(); j++ {
+				if t.Field(j).Name() == id.Name {
+					return t.Field(j).Type()
+				}
+			}
+			return nil
+		}
+		if key == nil && i < t.NumFields() {
+			return t.Field(i).Type()
+		}
+	}
+	return nil
+}
+
+// factory is a function used as a sweet.DepFactory.
+type factory struct {
+	pass *analysis.Pass
+	node ast.Node
+	body *ast.BlockStmt
+	t    *types.Var
+	dep  types.Type
+}
+
+// tName returns the name of the factory's *testing.T parameter, for messages.
+func (f factory) tName() string {
+	if name := f.t.Name(); name != "" && name != "_" {
+		return name
+	}
+	return "t"
+}
+
+// checkCleanup reports resources created by the factory that nothing closes.
+func (f factory) checkCleanup() {
+	cleansUp := false
+	closed := map[types.Object]bool{}
+	ast.Inspect(f.body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		switch sel.Sel.Name {
+		case "Cleanup":
+			if isTesting(f.pass.TypesInfo.TypeOf(sel.X)) {
+				cleansUp = true
+			}
+		case "Close":
+			if id, ok := astutil.Unparen(sel.X).(*ast.Ident); ok {
+				closed[f.pass.TypesInfo.Uses[id]] = true
+			}
+		}
+		return true
+	})
+	if cleansUp {
+		return
+	}
+
+	autoClosed := closerTypes(f.dep)
+
+	ast.Inspect(f.body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok && lit != f.node {
+			// Nested functions are checked on their own.
+			return false
+		}
+
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 {
+			return true
+		}
+		call, ok := astutil.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+		if !ok || f.passesT(call) {
+			// Other factories and helpers given the test clean up after
+			// themselves.
+			return true
+		}
+
+		for _, lhs := range assign.Lhs {
+			id, ok := lhs.(*ast.Ident)
+			if !ok || id.Name == "_" {
+				continue
+			}
+			obj := f.pass.TypesInfo.ObjectOf(id)
+			if obj == nil || closed[obj] || !hasClose(obj.Type()) {
+				continue
+			}
+			if closedBySweet(obj.Type(), autoClosed) {
+				continue
+			}
+
+			f.pass.Reportf(call.Pos(),
+				"factory creates %s, a %s, but never closes it; register a %s.Cleanup",
+				id.Name, types.TypeString(obj.Type(), types.RelativeTo(f.pass.Pkg)), f.tName())
+		}
+		return true
+	})
+}
+
+// passesT reports whether call is given the factory's *testing.T.
+func (f factory) passesT(call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		if id, ok := astutil.Unparen(arg).(*ast.Ident); ok && f.pass.TypesInfo.Uses[id] == f.t {
+			return true
+		}
+	}
+	return false
+}
+
+// checkContext reports contexts used by the factory that do not come from
+// the test.
+func (f factory) checkContext() {
+	reported := map[*types.Var]bool{}
+	ast.Inspect(f.body, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok && lit != f.node {
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			fn, ok := typeutil.Callee(f.pass.TypesInfo, n).(*types.Func)
+			if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "context" {
+				return true
+			}
+			if fn.Name() == "Background" || fn.Name() == "TODO" {
+				f.pass.Reportf(n.Pos(),
+					"factory uses context.%s(); use sweet.Context(%s) so setup stops with the test",
+					fn.Name(), f.tName())
+			}
+
+		case *ast.Ident:
+			v, ok := f.pass.TypesInfo.Uses[n].(*types.Var)
+			if !ok || v.IsField() || !isContext(v.Type()) {
+				return true
+			}
+			if reported[v] || (v.Pos() >= f.node.Pos() && v.Pos() < f.node.End()) {
+				return true
+			}
+			reported[v] = true
+			f.pass.Reportf(n.Pos(),
+				"factory uses context %s from outside the test; use sweet.Context(%s) so setup stops with the test",
+				n.Name, f.tName())
+		}
+		return true
+	})
+}
+
+// isSweetFunc reports whether call calls the sweet package's function name.
+func isSweetFunc(pass *analysis.Pass, call *ast.CallExpr, name string) bool {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return false
+	}
+	return fn.Pkg().Path() == sweetPath && fn.Name() == name
+}
+
+// depsParam returns the deps parameter of the test function lit, or nil if
+// it is not named.
+func depsParam(pass *analysis.Pass, lit *ast.FuncLit) *types.Var {
+	params := lit.Type.Params.List
+	if len(params) == 0 {
+		return nil
+	}
+
+	last := params[len(params)-1]
+	if len(last.Names) == 0 {
+		return nil
+	}
+	id := last.Names[len(last.Names)-1]
+	if id.Name == "_" {
+		return nil
+	}
+
+	v, _ := pass.TypesInfo.Defs[id].(*types.Var)
+	return v
+}
+
+// rootIdent returns the variable an assignment to expr writes to, or into.
+func rootIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// isDepFactory reports whether typ is a sweet.DepFactory.
+func isDepFactory(typ types.Type) bool {
+	return typ != nil && isNamed(typ, sweetPath, "DepFactory")
+}
+
+// isFactorySig reports whether sig has the shape of a sweet.DepFactory.
+func isFactorySig(sig *types.Signature) bool {
+	if sig.Recv() != nil || sig.Params().Len() != 1 || sig.Results().Len() != 1 {
+		return false
+	}
+	ptr, ok := sig.Params().At(0).Type().(*types.Pointer)
+	return ok && isNamed(ptr.Elem(), "testing", "T")
+}
+
+// isTesting reports whether typ is *testing.T or testing.TB.
+func isTesting(typ types.Type) bool {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		return isNamed(ptr.Elem(), "testing", "T")
+	}
+	return isNamed(typ, "testing", "TB")
+}
+
+func isContext(typ types.Type) bool {
+	return isNamed(typ, "context", "Context")
+}
+
+func isNamed(typ types.Type, pkg, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkg && obj.Name() == name
+}
+
+// hasClose reports whether typ has a Close method.
+func hasClose(typ types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, "Close")
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// closerTypes returns the types sweet closes when a factory returns dep: dep
+// itself, and the types of its exported fields, if they implement io.Closer.
+func closerTypes(dep types.Type) []types.Type {
+	var closers []types.Type
+	if isCloser(dep) {
+		closers = append(closers, dep)
+	}
+
+	under := dep
+	if ptr, ok := under.Underlying().(*types.Pointer); ok {
+		under = ptr.Elem()
+	}
+	st, ok := under.Underlying().(*types.Struct)
+	if !ok {
+		return closers
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if field.Exported() && isCloser(field.Type()) {
+			closers = append(closers, field.Type())
+		}
+	}
+	return closers
+}
+
+// closedBySweet reports whether typ is one of closers.
+func closedBySweet(typ types.Type, closers []types.Type) bool {
+	for _, closer := range closers {
+		if types.Identical(typ, closer) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCloser reports whether typ implements io.Closer.
+func isCloser(typ types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, nil, "Close")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	return types.Identical(sig.Results().At(0).Type(), types.Universe.Lookup("error").Type())
+}
@@ -0,0 +1,12 @@
+package sweetvet_test
+
+import (
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet/sweetvet"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), sweetvet.Analyzer, "a")
+}
@@ -0,0 +1,167 @@
+package a
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/barry-hennessy/test/sweet"
+)
+
+type deps struct {
+	db    map[string]bool
+	count int
+}
+
+func depsFactory(t *testing.T) *deps {
+	return &deps{db: map[string]bool{}}
+}
+
+func nested(t *testing.T) {
+	sweet.Run(t, "outer", depsFactory, func(t *testing.T, d *deps) {
+		d.count++
+
+		sweet.Run(t, "shadows", depsFactory, func(t *testing.T, d *deps) { // want `deps parameter d shadows the deps of the enclosing sweet.Run test`
+			d.count++
+		})
+
+		sweet.Run(t, "writes", depsFactory, func(t *testing.T, inner *deps) {
+			inner.count++
+			d.db["writes"] = true // want `nested sweet.Run test writes to d, the deps of an enclosing test`
+			d.count++             // want `nested sweet.Run test writes to d, the deps of an enclosing test`
+			_ = d.count
+
+			t.Run("plain subtest", func(t *testing.T) {
+				inner.count = 2
+			})
+		})
+
+		t.Run("plain subtest", func(t *testing.T) {
+			d.count = 2
+		})
+	})
+}
+
+type file struct {
+	F *os.File
+}
+
+func leaks(t *testing.T) string {
+	f, err := os.Open("leaks") // want `factory creates f, a \*os.File, but never closes it; register a t.Cleanup`
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Name()
+}
+
+func cleansUp(t *testing.T) string {
+	f, err := os.Open("cleans up")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		f.Close()
+	})
+	return f.Name()
+}
+
+func closesItself(t *testing.T) string {
+	f, _ := os.Open("closes itself")
+	defer f.Close()
+	return f.Name()
+}
+
+func openFile(t *testing.T) *os.File {
+	f, err := os.Open("helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		f.Close()
+	})
+	return f
+}
+
+func usesHelper(t *testing.T) string {
+	f := openFile(t)
+	return f.Name()
+}
+
+func closedBySweet(t *testing.T) *os.File {
+	f, _ := os.Open("closed by sweet")
+	return f
+}
+
+func fieldClosedBySweet(t *testing.T) file {
+	f, _ := os.Open("closed by sweet")
+	return file{F: f}
+}
+
+var packageCtx = context.Background()
+
+func background(t *testing.T) context.Context {
+	return context.Background() // want `factory uses context.Background\(\); use sweet.Context\(t\)`
+}
+
+func captures(ctx context.Context) sweet.DepFactory[string] {
+	return func(t *testing.T) string {
+		_ = packageCtx // want `factory uses context packageCtx from outside the test`
+		_ = ctx        // want `factory uses context ctx from outside the test`
+		_ = ctx
+		return ""
+	}
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithCancel(sweet.Context(t))
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func run[D any](t *testing.T, factory sweet.DepFactory[D]) {
+	sweet.Run(t, "test", factory, func(t *testing.T, d D) {})
+}
+
+// factories uses the functions above as factories.
+func factories(t *testing.T) {
+	sweet.Run(t, "leaks", leaks, func(t *testing.T, name string) {})
+	sweet.Run(t, "cleans up", cleansUp, func(t *testing.T, name string) {})
+	run(t, closesItself)
+	run(t, usesHelper)
+	run(t, closedBySweet)
+	run(t, fieldClosedBySweet)
+	run(t, sweet.DepFactory[context.Context](background))
+	run(t, testContext)
+	run(t, captures(context.Background()))
+
+	var assigned sweet.DepFactory[string] = func(t *testing.T) string {
+		return fmt.Sprint(context.TODO()) // want `factory uses context.TODO\(\); use sweet.Context\(t\)`
+	}
+	run(t, assigned)
+
+	byName := map[string]sweet.DepFactory[string]{
+		"listed": func(t *testing.T) string {
+			f, _ := os.Open("listed") // want `factory creates f, a \*os.File, but never closes it; register a t.Cleanup`
+			return f.Name()
+		},
+	}
+	run(t, byName["listed"])
+}
+
+// Helpers taking the test aren't factories, whatever they return.
+
+func helper(t *testing.T) string {
+	f, _ := os.Open("helper")
+	t.Cleanup(func() {
+		f.Close()
+	})
+	return fmt.Sprint(context.Background())
+}
+
+func helperLit(t *testing.T) {
+	name := func(t *testing.T) string {
+		return fmt.Sprint(context.Background())
+	}
+	_ = name(t)
+}
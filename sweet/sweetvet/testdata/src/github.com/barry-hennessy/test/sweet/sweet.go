@@ -0,0 +1,18 @@
+// Package sweet is a stand in for the real sweet package in the analyzer's
+// tests.
+package sweet
+
+import (
+	"context"
+	"testing"
+)
+
+type DepFactory[deps any] func(t *testing.T) deps
+
+func Run[deps any](t *testing.T, testName string, factory DepFactory[deps], coreTest func(t *testing.T, d deps)) bool {
+	return true
+}
+
+func Context(t *testing.T) context.Context {
+	return context.Background()
+}